@@ -24,6 +24,23 @@ type Config struct {
 		URL      string `mapstructure:"URL"`
 	}
 
+	Blob struct {
+		BaseURL string `mapstructure:"BASE_URL"`
+		Driver  string `mapstructure:"DRIVER"`
+
+		Local struct {
+			Root string `mapstructure:"ROOT"`
+		}
+
+		S3 struct {
+			AccessKeyID     string `mapstructure:"ACCESS_KEY_ID"`
+			Bucket          string `mapstructure:"BUCKET"`
+			Endpoint        string `mapstructure:"ENDPOINT"`
+			Region          string `mapstructure:"REGION"`
+			SecretAccessKey string `mapstructure:"SECRET_ACCESS_KEY"`
+		}
+	}
+
 	Cache struct {
 		Redis struct {
 			Primary struct {
@@ -110,6 +127,56 @@ type Config struct {
 		}
 	}
 
+	Image struct {
+		ContentTypes []string `mapstructure:"CONTENT_TYPES"`
+		MaxPixels    int64    `mapstructure:"MAX_PIXELS"`
+		MaxSizeBytes int64    `mapstructure:"MAX_SIZE_BYTES"`
+
+		Renditions struct {
+			Formats  []string `mapstructure:"FORMATS"`
+			MaxRetry int      `mapstructure:"MAX_RETRY"`
+			Quality  int      `mapstructure:"QUALITY"`
+			Widths   []int    `mapstructure:"WIDTHS"`
+		}
+	}
+
+	Import struct {
+		BatchSize         int   `mapstructure:"BATCH_SIZE"`
+		MaxSizeBytes      int64 `mapstructure:"MAX_SIZE_BYTES"`
+		StaleAfterSeconds int64 `mapstructure:"STALE_AFTER_SECONDS"`
+	}
+
+	Inventory struct {
+		ReservationTTLSeconds int64 `mapstructure:"RESERVATION_TTL_SECONDS"`
+		SweepIntervalSeconds  int64 `mapstructure:"SWEEP_INTERVAL_SECONDS"`
+	}
+
+	OAuth struct {
+		ClientScope              []string `mapstructure:"CLIENT_SCOPE"`
+		ExpirationSeconds        int64    `mapstructure:"EXPIRATION_SECONDS"`
+		Issuer                   string   `mapstructure:"ISSUER"`
+		RefreshExpirationSeconds int64    `mapstructure:"REFRESH_EXPIRATION_SECONDS"`
+		TokenFormat              string   `mapstructure:"TOKEN_FORMAT"`
+
+		Cache struct {
+			Enabled       bool  `mapstructure:"ENABLED"`
+			MaxTTLSeconds int64 `mapstructure:"MAX_TTL_SECONDS"`
+		}
+
+		JWT struct {
+			KeyFiles []string `mapstructure:"KEY_FILES"`
+		}
+	}
+
+	Pagination struct {
+		CursorSecret string `mapstructure:"CURSOR_SECRET"`
+	}
+
+	PubSub struct {
+		MessageBuffer int `mapstructure:"MESSAGE_BUFFER"`
+		Workers       int `mapstructure:"WORKERS"`
+	}
+
 	Server struct {
 		Env      string `mapstructure:"ENV"`
 		LogLevel string `mapstructure:"LOG_LEVEL"`
@@ -119,6 +186,10 @@ type Config struct {
 			GracePeriodSeconds   int64 `mapstructure:"GRACE_PERIOD_SECONDS"`
 		}
 	}
+
+	Variant struct {
+		LimitedThreshold int `mapstructure:"LIMITED_THRESHOLD"`
+	}
 }
 
 var (
@@ -0,0 +1,32 @@
+package infras
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/blob"
+	"github.com/rs/zerolog/log"
+)
+
+// ProvideBlobStore is the provider for the blob.Store uploads are kept in,
+// selected by BLOB.DRIVER.
+func ProvideBlobStore(config *configs.Config) blob.Store {
+	switch config.Blob.Driver {
+	case "", "local":
+		return blob.NewLocalStore(config.Blob.Local.Root, config.Blob.BaseURL)
+	case "s3":
+		store, err := blob.NewS3Store(blob.S3Config{
+			Endpoint:        config.Blob.S3.Endpoint,
+			Region:          config.Blob.S3.Region,
+			Bucket:          config.Blob.S3.Bucket,
+			AccessKeyID:     config.Blob.S3.AccessKeyID,
+			SecretAccessKey: config.Blob.S3.SecretAccessKey,
+			BaseURL:         config.Blob.BaseURL,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed creating S3 blob store")
+		}
+		return store
+	}
+
+	log.Fatal().Str("driver", config.Blob.Driver).Msg("Unknown blob store driver")
+	return nil
+}
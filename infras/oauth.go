@@ -0,0 +1,64 @@
+package infras
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/rs/zerolog/log"
+)
+
+// ProvideOauthConfig is the provider for the oauth.Config shared by the token
+// endpoints and the authentication middleware. JWT signing keys are read once
+// here, the first configured key file being the one new tokens are signed with.
+func ProvideOauthConfig(config *configs.Config) *oauth.Config {
+	format := oauth.TokenFormat(config.OAuth.TokenFormat)
+	if format == "" {
+		format = oauth.Opaque
+	}
+	if format != oauth.Opaque && format != oauth.JWT {
+		log.Fatal().Str("format", string(format)).Msg("Unknown OAuth token format")
+	}
+
+	var keys []oauth.SigningKey
+	for _, file := range config.OAuth.JWT.KeyFiles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal().Err(err).Str("file", file).Msg("Failed reading JWT signing key")
+		}
+
+		key, err := oauth.ParseSigningKeyPEM(data)
+		if err != nil {
+			log.Fatal().Err(err).Str("file", file).Msg("Failed parsing JWT signing key")
+		}
+		keys = append(keys, key)
+	}
+
+	oauthConfig := &oauth.Config{
+		Expiration:        config.OAuth.ExpirationSeconds,
+		RefreshExpiration: config.OAuth.RefreshExpirationSeconds,
+		ClientScope:       config.OAuth.ClientScope,
+		Format:            format,
+		Issuer:            config.OAuth.Issuer,
+	}
+	if len(keys) > 0 {
+		oauthConfig.Keys = oauth.NewKeySet(keys...)
+	}
+	if format == oauth.JWT && oauthConfig.Keys == nil {
+		log.Fatal().Msg("JWT access tokens require at least one signing key")
+	}
+	if config.OAuth.Cache.Enabled {
+		oauthConfig.Cache = oauth.NewTokenCache(
+			RedisNewClient(*config),
+			time.Duration(config.OAuth.Cache.MaxTTLSeconds)*time.Second)
+	}
+
+	return oauthConfig
+}
+
+// ProvideOauthToken is the provider for the oauth.Token run outside of
+// requests, such as the startup hashing of client secrets.
+func ProvideOauthToken(db *MariaDBConn, oauthConfig *oauth.Config) *oauth.Token {
+	return oauth.New(db.Write, *oauthConfig)
+}
@@ -0,0 +1,16 @@
+package infras
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/rs/zerolog/log"
+)
+
+// ProvideCursorCodec is the provider for the codec of the opaque cursors used
+// by keyset paginated endpoints.
+func ProvideCursorCodec(config *configs.Config) *pagination.CursorCodec {
+	if config.Pagination.CursorSecret == "" {
+		log.Warn().Msg("No pagination cursor secret configured, cursors will not survive a restart")
+	}
+	return pagination.NewCursorCodec(config.Pagination.CursorSecret)
+}
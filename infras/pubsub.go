@@ -0,0 +1,24 @@
+package infras
+
+import (
+	"sync"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared"
+)
+
+var (
+	pubsub     shared.PubSub
+	pubsubOnce sync.Once
+)
+
+// ProvidePubSub is the provider for the in-process PubSub worker pool. Its
+// messages never leave the process, so every injector shares a single started
+// pool, letting publishers and subscribers wired apart reach each other.
+func ProvidePubSub(config *configs.Config) shared.PubSub {
+	pubsubOnce.Do(func() {
+		pubsub = shared.New(config.PubSub.Workers, shared.SetMessageBuffer(config.PubSub.MessageBuffer))
+		pubsub.Start()
+	})
+	return pubsub
+}
@@ -1,19 +1,103 @@
 package brand
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
 )
 
 type Brand struct {
-	BrandID   uuid.UUID
-	BrandName string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt null.Time
-	CreatedBy uuid.UUID
-	UpdatedBy uuid.UUID
-	DeletedBy uuid.UUID
+	BrandID   uuid.UUID   `db:"brand_id" validate:"required"`
+	BrandName string      `db:"brand_name" validate:"required,max=100"`
+	CreatedAt time.Time   `db:"created_at" validate:"required"`
+	UpdatedAt time.Time   `db:"updated_at" validate:"required"`
+	DeletedAt null.Time   `db:"deleted_at"`
+	CreatedBy uuid.UUID   `db:"created_by" validate:"required"`
+	UpdatedBy uuid.UUID   `db:"updated_by" validate:"required"`
+	DeletedBy nuuid.NUUID `db:"deleted_by"`
+}
+
+type PayloadBrand struct {
+	BrandName string `json:"brandName" validate:"required"`
+}
+
+type BrandResponseFormat struct {
+	BrandID   uuid.UUID   `json:"brandId"`
+	BrandName string      `json:"brandName"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	DeletedAt null.Time   `json:"deletedAt"`
+	CreatedBy uuid.UUID   `json:"createdBy"`
+	UpdatedBy uuid.UUID   `json:"updatedBy"`
+	DeletedBy nuuid.NUUID `json:"deletedBy"`
+}
+
+func (b Brand) NewFromPayload(payload PayloadBrand, userID uuid.UUID) (Brand, error) {
+	brandId, _ := uuid.NewV4()
+	newBrand := Brand{
+		BrandID:   brandId,
+		BrandName: payload.BrandName,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: userID,
+		UpdatedAt: time.Now().UTC(),
+		UpdatedBy: userID,
+	}
+
+	err := newBrand.Validate()
+	return newBrand, err
+}
+
+func (b *Brand) ToResponseFormat() BrandResponseFormat {
+	resp := BrandResponseFormat{
+		BrandID:   b.BrandID,
+		BrandName: b.BrandName,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+		DeletedAt: b.DeletedAt,
+		CreatedBy: b.CreatedBy,
+		UpdatedBy: b.UpdatedBy,
+		DeletedBy: b.DeletedBy,
+	}
+	return resp
+}
+
+func (b *Brand) Validate() error {
+	validator := shared.GetValidator()
+	return validator.Struct(b)
+}
+
+func (b Brand) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.ToResponseFormat())
+}
+
+func (b *Brand) IsDeleted() (deleted bool) {
+	return b.DeletedAt.Valid && b.DeletedBy.Valid
+}
+
+func (b *Brand) SoftDelete(userID uuid.UUID) (err error) {
+	if b.IsDeleted() {
+		return failure.Conflict("softDelete", "Brand", "already marked as deleted")
+	}
+
+	b.DeletedAt = null.TimeFrom(time.Now().UTC())
+	b.DeletedBy = nuuid.From(userID)
+	return
+}
+
+func (b *Brand) Update(req PayloadBrand, userID uuid.UUID) (err error) {
+	if b.IsDeleted() {
+		return failure.Conflict("update", "Brand", "already marked as deleted")
+	}
+
+	b.UpdatedAt = time.Now().UTC()
+	b.UpdatedBy = userID
+	b.BrandName = req.BrandName
+
+	err = b.Validate()
+	return
 }
@@ -0,0 +1,252 @@
+package brand
+
+import (
+	"database/sql"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/products"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	brandQueries = struct {
+		selectBrand   string
+		insertBrand   string
+		updateBrand   string
+		deleteBrand   string
+		selectByBrand string
+		countBrand    string
+		countByBrand  string
+	}{
+		selectBrand: `
+			SELECT
+				brand_id,
+				brand_name,
+				created_at,
+				updated_at,
+				deleted_at,
+				created_by,
+				updated_by,
+				deleted_by
+			FROM brand`,
+
+		insertBrand: `
+			INSERT INTO brand (brand_id, brand_name, created_at, updated_at, created_by, updated_by)
+			VALUES (:brand_id, :brand_name, :created_at, :updated_at, :created_by, :updated_by)`,
+
+		updateBrand: `
+			UPDATE brand
+			SET
+				brand_name = :brand_name,
+				updated_at = :updated_at,
+				updated_by = :updated_by,
+				deleted_at = :deleted_at,
+				deleted_by = :deleted_by
+			WHERE brand_id = :brand_id`,
+
+		deleteBrand: `DELETE FROM brand WHERE brand_id = ?`,
+
+		selectByBrand: `
+			SELECT
+				product_id,
+				user_id,
+				brand_id,
+				product_name,
+				limited_threshold,
+				created_at,
+				updated_at,
+				deleted_at,
+				created_by,
+				updated_by,
+				deleted_by
+			FROM product
+			WHERE brand_id = ? AND deleted_at IS NULL`,
+
+		countBrand: `SELECT COUNT(*) FROM brand WHERE deleted_at IS NULL`,
+
+		countByBrand: `SELECT COUNT(*) FROM product WHERE brand_id = ? AND deleted_at IS NULL`,
+	}
+)
+
+type BrandRepository interface {
+	Create(brand Brand) (err error)
+	GetAllBrands(sort string, limit, offset int) (brands []Brand, err error)
+	GetBrandByID(brandId uuid.UUID) (brand Brand, err error)
+	GetProductsByBrandID(brandId uuid.UUID, sort string, limit, offset int) (prods []products.Product, err error)
+	CountBrands() (total int, err error)
+	CountProductsByBrandID(brandId uuid.UUID) (total int, err error)
+	ExistsByID(brandId uuid.UUID) (exists bool, err error)
+	Update(brand Brand) (err error)
+	HardDelete(brandId uuid.UUID) (err error)
+}
+
+type BrandRepositoryMariaDB struct {
+	DB *infras.MariaDBConn
+}
+
+func ProvideBrandRepositoryMariaDB(db *infras.MariaDBConn) *BrandRepositoryMariaDB {
+	s := new(BrandRepositoryMariaDB)
+	s.DB = db
+	return s
+}
+
+func (r *BrandRepositoryMariaDB) Create(brand Brand) (err error) {
+	exists, err := r.ExistsByID(brand.BrandID)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if exists {
+		err = failure.Conflict("create", "brand", "already exists")
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txCreate(tx, brand); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+// GetAllBrands lists the Brands not soft deleted.
+func (r *BrandRepositoryMariaDB) GetAllBrands(sort string, limit, offset int) (brands []Brand, err error) {
+	// sort is always either ASC or DESC, see pagination.GetSortDirection
+	query := brandQueries.selectBrand + " WHERE deleted_at IS NULL ORDER BY brand_name " + sort + " LIMIT ? OFFSET ?"
+	err = r.DB.Read.Select(&brands, query, limit, offset)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+func (r *BrandRepositoryMariaDB) GetBrandByID(brandId uuid.UUID) (brand Brand, err error) {
+	err = r.DB.Read.Get(&brand, brandQueries.selectBrand+" WHERE brand_id = ?", brandId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("brand")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+// GetProductsByBrandID lists the Products of a Brand not soft deleted.
+func (r *BrandRepositoryMariaDB) GetProductsByBrandID(brandId uuid.UUID, sort string, limit, offset int) (prods []products.Product, err error) {
+	query := brandQueries.selectByBrand + " ORDER BY created_at " + sort + " LIMIT ? OFFSET ?"
+	err = r.DB.Read.Select(&prods, query, brandId.String(), limit, offset)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+func (r *BrandRepositoryMariaDB) CountBrands() (total int, err error) {
+	err = r.DB.Read.Get(&total, brandQueries.countBrand)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *BrandRepositoryMariaDB) CountProductsByBrandID(brandId uuid.UUID) (total int, err error) {
+	err = r.DB.Read.Get(&total, brandQueries.countByBrand, brandId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *BrandRepositoryMariaDB) ExistsByID(brandId uuid.UUID) (exists bool, err error) {
+	err = r.DB.Read.Get(&exists, "SELECT COUNT(brand_id) FROM brand WHERE brand_id = ?", brandId.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *BrandRepositoryMariaDB) Update(brand Brand) (err error) {
+	exists, err := r.ExistsByID(brand.BrandID)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if !exists {
+		err = failure.NotFound("brand")
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txUpdate(tx, brand); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+func (r *BrandRepositoryMariaDB) HardDelete(brandId uuid.UUID) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txDelete(tx, brandId); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+func (r *BrandRepositoryMariaDB) txCreate(tx *sqlx.Tx, brand Brand) (err error) {
+	stmt, err := tx.PrepareNamed(brandQueries.insertBrand)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(brand)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *BrandRepositoryMariaDB) txUpdate(tx *sqlx.Tx, brand Brand) (err error) {
+	stmt, err := tx.PrepareNamed(brandQueries.updateBrand)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(brand)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *BrandRepositoryMariaDB) txDelete(tx *sqlx.Tx, brandId uuid.UUID) (err error) {
+	_, err = tx.Exec(brandQueries.deleteBrand, brandId.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
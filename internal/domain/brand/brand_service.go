@@ -0,0 +1,102 @@
+package brand
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/internal/domain/products"
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/gofrs/uuid"
+)
+
+type BrandService interface {
+	Create(payload PayloadBrand, userID uuid.UUID) (brand Brand, err error)
+	GetAllBrands(pg pagination.Pagination) (brands []Brand, total int, err error)
+	GetBrandByID(brandId uuid.UUID) (brand Brand, err error)
+	GetProductsByBrandID(brandId uuid.UUID, pg pagination.Pagination) (prods []products.Product, total int, err error)
+	Update(brandId uuid.UUID, payload PayloadBrand, userID uuid.UUID) (brand Brand, err error)
+	SoftDelete(brandId uuid.UUID, userID uuid.UUID) (brand Brand, err error)
+	HardDelete(brandId uuid.UUID) (err error)
+}
+
+type BrandServiceImpl struct {
+	BrandRepository BrandRepository
+	Config          *configs.Config
+}
+
+func ProvideBrandServiceImpl(brandRepo BrandRepository, config *configs.Config) *BrandServiceImpl {
+	s := new(BrandServiceImpl)
+	s.BrandRepository = brandRepo
+	s.Config = config
+
+	return s
+}
+
+func (s *BrandServiceImpl) Create(payload PayloadBrand, userID uuid.UUID) (brand Brand, err error) {
+	brand, err = brand.NewFromPayload(payload, userID)
+	if err != nil {
+		return
+	}
+	err = s.BrandRepository.Create(brand)
+	return
+}
+
+func (s *BrandServiceImpl) GetAllBrands(pg pagination.Pagination) (brands []Brand, total int, err error) {
+	brands, err = s.BrandRepository.GetAllBrands(pg.Sort, pg.Limit, pg.Offset)
+	if err != nil {
+		return
+	}
+	total, err = s.BrandRepository.CountBrands()
+	return
+}
+
+func (s *BrandServiceImpl) GetBrandByID(brandId uuid.UUID) (brand Brand, err error) {
+	brand, err = s.BrandRepository.GetBrandByID(brandId)
+	return
+}
+
+func (s *BrandServiceImpl) GetProductsByBrandID(brandId uuid.UUID, pg pagination.Pagination) (prods []products.Product, total int, err error) {
+	_, err = s.BrandRepository.GetBrandByID(brandId)
+	if err != nil {
+		return
+	}
+	prods, err = s.BrandRepository.GetProductsByBrandID(brandId, pg.Sort, pg.Limit, pg.Offset)
+	if err != nil {
+		return
+	}
+	total, err = s.BrandRepository.CountProductsByBrandID(brandId)
+	return
+}
+
+func (s *BrandServiceImpl) Update(brandId uuid.UUID, payload PayloadBrand, userID uuid.UUID) (brand Brand, err error) {
+	brand, err = s.BrandRepository.GetBrandByID(brandId)
+	if err != nil {
+		return
+	}
+	err = brand.Update(payload, userID)
+	if err != nil {
+		return
+	}
+	err = s.BrandRepository.Update(brand)
+	return
+}
+
+func (s *BrandServiceImpl) SoftDelete(brandId uuid.UUID, userID uuid.UUID) (brand Brand, err error) {
+	brand, err = s.BrandRepository.GetBrandByID(brandId)
+	if err != nil {
+		return
+	}
+	err = brand.SoftDelete(userID)
+	if err != nil {
+		return
+	}
+	err = s.BrandRepository.Update(brand)
+	return
+}
+
+func (s *BrandServiceImpl) HardDelete(brandId uuid.UUID) (err error) {
+	_, err = s.BrandRepository.GetBrandByID(brandId)
+	if err != nil {
+		return
+	}
+	err = s.BrandRepository.HardDelete(brandId)
+	return
+}
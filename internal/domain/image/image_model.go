@@ -1,6 +1,8 @@
 package image
 
 import (
+	"encoding/json"
+	"path"
 	"time"
 
 	"github.com/evermos/boilerplate-go/shared"
@@ -9,35 +11,125 @@ import (
 	"github.com/guregu/null"
 )
 
+// Image is a picture of a Variant. Uploaded Images are kept in the blob store
+// under BlobKey; others only link to ImageURL.
 type Image struct {
-	ImageID   uuid.UUID   `db:"image_id"`
-	VariantID uuid.UUID   `db:"variant_id"`
-	ImageURL  string      `db:"image_url"`
-	CreatedAt time.Time   `db:"created_at"`
-	UpdatedAt time.Time   `db:"updated_at"`
-	DeletedAt null.Time   `db:"deleted_at"`
-	CreatedBy uuid.UUID   `db:"created_by"`
-	UpdatedBy uuid.UUID   `db:"updated_by"`
-	DeletedBy nuuid.NUUID `db:"deleted_by"`
-}
-
-func (i Image) NewFromPayload(urls string, variantId uuid.UUID) (Image, error) {
+	ImageID     uuid.UUID   `db:"image_id"`
+	VariantID   uuid.UUID   `db:"variant_id"`
+	ImageURL    string      `db:"image_url" validate:"required,url,max=500"`
+	Position    int         `db:"position" validate:"min=0"`
+	BlobKey     null.String `db:"blob_key"`
+	ContentType null.String `db:"content_type"`
+	Size        null.Int    `db:"size"`
+	CreatedAt   time.Time   `db:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at"`
+	DeletedAt   null.Time   `db:"deleted_at"`
+	CreatedBy   uuid.UUID   `db:"created_by"`
+	UpdatedBy   uuid.UUID   `db:"updated_by"`
+	DeletedBy   nuuid.NUUID `db:"deleted_by"`
+	Renditions  []Rendition `db:"-"`
+}
+
+// PayloadReorder lists every Image of a Variant in their new order.
+type PayloadReorder struct {
+	ImageIDs []uuid.UUID `json:"imageIds" validate:"required,min=1"`
+}
+
+type ImageResponseFormat struct {
+	ImageID     uuid.UUID                 `json:"imageId"`
+	VariantID   uuid.UUID                 `json:"variantId"`
+	URL         string                    `json:"url"`
+	Position    int                       `json:"position"`
+	ContentType null.String               `json:"contentType"`
+	Size        null.Int                  `json:"size"`
+	Renditions  []RenditionResponseFormat `json:"renditions"`
+	CreatedAt   time.Time                 `json:"createdAt"`
+	UpdatedAt   time.Time                 `json:"updatedAt"`
+}
+
+// extensions are the file extensions of the uploaded content types.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// NewFromPayload creates an Image linking to url, at position among the
+// Images of its Variant.
+func (i Image) NewFromPayload(url string, variantId uuid.UUID, userID uuid.UUID, position int) (Image, error) {
 	imgId, _ := uuid.NewV4()
 	newImg := Image{
 		ImageID:   imgId,
 		VariantID: variantId,
-		ImageURL:  urls,
+		ImageURL:  url,
+		Position:  position,
 		CreatedAt: time.Now().UTC(),
-		CreatedBy: variantId,
+		CreatedBy: userID,
 		UpdatedAt: time.Now().UTC(),
-		UpdatedBy: variantId,
+		UpdatedBy: userID,
 	}
 	err := newImg.Validate()
 
 	return newImg, err
 }
 
+// NewUpload creates an Image to be uploaded, with the blob key it is to be
+// stored under. Its ImageURL is left to the blob store to tell.
+func (i Image) NewUpload(variantId uuid.UUID, userID uuid.UUID, contentType string, size int64, position int) Image {
+	imgId, _ := uuid.NewV4()
+	return Image{
+		ImageID:     imgId,
+		VariantID:   variantId,
+		Position:    position,
+		BlobKey:     null.StringFrom(path.Join("images", variantId.String(), imgId.String()+extensions[contentType])),
+		ContentType: null.StringFrom(contentType),
+		Size:        null.IntFrom(size),
+		CreatedAt:   time.Now().UTC(),
+		CreatedBy:   userID,
+		UpdatedAt:   time.Now().UTC(),
+		UpdatedBy:   userID,
+	}
+}
+
+// IsUploaded reports whether the Image is kept in the blob store.
+func (i *Image) IsUploaded() bool {
+	return i.BlobKey.Valid
+}
+
 func (i *Image) Validate() error {
 	validator := shared.GetValidator()
 	return validator.Struct(i)
 }
+
+// AttachRenditions sets the Renditions of the Image among rends.
+func (i *Image) AttachRenditions(rends []Rendition) {
+	i.Renditions = []Rendition{}
+	for _, rend := range rends {
+		if rend.ImageID == i.ImageID {
+			i.Renditions = append(i.Renditions, rend)
+		}
+	}
+}
+
+func (i *Image) ToResponseFormat() ImageResponseFormat {
+	rends := make([]RenditionResponseFormat, 0, len(i.Renditions))
+	for j := range i.Renditions {
+		rends = append(rends, i.Renditions[j].ToResponseFormat())
+	}
+	return ImageResponseFormat{
+		ImageID:     i.ImageID,
+		VariantID:   i.VariantID,
+		URL:         i.ImageURL,
+		Position:    i.Position,
+		ContentType: i.ContentType,
+		Size:        i.Size,
+		Renditions:  rends,
+		CreatedAt:   i.CreatedAt,
+		UpdatedAt:   i.UpdatedAt,
+	}
+}
+
+func (i Image) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.ToResponseFormat())
+}
@@ -0,0 +1,39 @@
+package image_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/image"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageNewUpload(t *testing.T) {
+	variantID, _ := uuid.NewV4()
+	userID, _ := uuid.NewV4()
+
+	img := image.Image{}.NewUpload(variantID, userID, "image/png", 1024, 2)
+	assert.True(t, img.IsUploaded())
+	assert.Equal(t, "images/"+variantID.String()+"/"+img.ImageID.String()+".png", img.BlobKey.String)
+	assert.Equal(t, 2, img.Position)
+	assert.Error(t, img.Validate())
+
+	img.ImageURL = "http://localhost:8080/blobs/" + img.BlobKey.String
+	assert.NoError(t, img.Validate())
+}
+
+func TestImageNewFromPayload(t *testing.T) {
+	variantID, _ := uuid.NewV4()
+	userID, _ := uuid.NewV4()
+
+	img, err := image.Image{}.NewFromPayload("https://cdn.example.com/a.jpg", variantID, userID, 0)
+	assert.NoError(t, err)
+	assert.False(t, img.IsUploaded())
+
+	_, err = image.Image{}.NewFromPayload("not a url", variantID, userID, 0)
+	assert.Error(t, err)
+
+	_, err = image.Image{}.NewFromPayload("https://cdn.example.com/"+strings.Repeat("a", 500), variantID, userID, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,229 @@
+package image
+
+import (
+	"database/sql"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	imageQueries = struct {
+		selectImage    string
+		insertImage    string
+		updatePosition string
+		deleteImage    string
+		nextPosition   string
+		variantExists  string
+
+		selectRenditions string
+		insertRendition  string
+		deleteRenditions string
+	}{
+		selectImage: `
+			SELECT
+				image_id,
+				variant_id,
+				image_url,
+				position,
+				blob_key,
+				content_type,
+				size,
+				created_at,
+				updated_at,
+				deleted_at,
+				created_by,
+				updated_by,
+				deleted_by
+			FROM image`,
+
+		insertImage: `
+			INSERT INTO image (image_id, variant_id, image_url, position, blob_key, content_type, size, created_at, updated_at, created_by, updated_by)
+			VALUES (:image_id, :variant_id, :image_url, :position, :blob_key, :content_type, :size, :created_at, :updated_at, :created_by, :updated_by)`,
+
+		updatePosition: `
+			UPDATE image
+			SET position = ?, updated_at = CURRENT_TIMESTAMP, updated_by = ?
+			WHERE image_id = ? AND variant_id = ?`,
+
+		deleteImage: `DELETE FROM image WHERE image_id = ?`,
+
+		nextPosition: `SELECT COALESCE(MAX(position) + 1, 0) FROM image WHERE variant_id = ?`,
+
+		variantExists: `SELECT COUNT(variant_id) FROM variant WHERE variant_id = ? AND deleted_at IS NULL`,
+
+		selectRenditions: `
+			SELECT image_id, width, height, format, blob_key, url, size, created_at
+			FROM image_rendition
+			WHERE image_id IN (?)
+			ORDER BY format, width`,
+
+		insertRendition: `
+			INSERT INTO image_rendition (image_id, width, height, format, blob_key, url, size, created_at)
+			VALUES (:image_id, :width, :height, :format, :blob_key, :url, :size, :created_at)`,
+
+		deleteRenditions: `DELETE FROM image_rendition WHERE image_id = ?`,
+	}
+)
+
+type ImageRepository interface {
+	VariantExistsByID(variantId uuid.UUID) (exists bool, err error)
+	GetImagesByVariantID(variantId uuid.UUID) (imgs []Image, err error)
+	GetImageByID(imageId uuid.UUID) (img Image, err error)
+	NextPosition(variantId uuid.UUID) (position int, err error)
+	Create(img Image) (err error)
+	Reorder(variantId uuid.UUID, imageIds []uuid.UUID, userID uuid.UUID) (err error)
+	Delete(imageId uuid.UUID) (err error)
+	ReplaceRenditions(imageId uuid.UUID, rends []Rendition) (err error)
+}
+
+type ImageRepositoryMariaDB struct {
+	DB *infras.MariaDBConn
+}
+
+func ProvideImageRepositoryMariaDB(db *infras.MariaDBConn) *ImageRepositoryMariaDB {
+	return &ImageRepositoryMariaDB{
+		DB: db,
+	}
+}
+
+// VariantExistsByID reports whether a Variant exists and is not soft deleted.
+func (r *ImageRepositoryMariaDB) VariantExistsByID(variantId uuid.UUID) (exists bool, err error) {
+	err = r.DB.Read.Get(&exists, imageQueries.variantExists, variantId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *ImageRepositoryMariaDB) GetImagesByVariantID(variantId uuid.UUID) (imgs []Image, err error) {
+	err = r.DB.Read.Select(&imgs, imageQueries.selectImage+" WHERE variant_id = ? ORDER BY position, created_at, image_id", variantId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = AttachRenditions(r.DB.Read, imgs)
+	return
+}
+
+func (r *ImageRepositoryMariaDB) GetImageByID(imageId uuid.UUID) (img Image, err error) {
+	err = r.DB.Read.Get(&img, imageQueries.selectImage+" WHERE image_id = ?", imageId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("image")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	imgs := []Image{img}
+	err = AttachRenditions(r.DB.Read, imgs)
+	img = imgs[0]
+	return
+}
+
+// NextPosition returns the position following the last Image of a Variant.
+func (r *ImageRepositoryMariaDB) NextPosition(variantId uuid.UUID) (position int, err error) {
+	err = r.DB.Read.Get(&position, imageQueries.nextPosition, variantId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *ImageRepositoryMariaDB) Create(img Image) (err error) {
+	_, err = r.DB.Write.NamedExec(imageQueries.insertImage, img)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// Reorder moves the Images of a Variant to the position of their ID in
+// imageIds.
+func (r *ImageRepositoryMariaDB) Reorder(variantId uuid.UUID, imageIds []uuid.UUID, userID uuid.UUID) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		for position, imageId := range imageIds {
+			_, err := tx.Exec(imageQueries.updatePosition, position, userID.String(), imageId.String(), variantId.String())
+			if err != nil {
+				logger.ErrorWithStack(err)
+				c <- err
+				return
+			}
+		}
+		c <- nil
+	})
+}
+
+func (r *ImageRepositoryMariaDB) Delete(imageId uuid.UUID) (err error) {
+	_, err = r.DB.Write.Exec(imageQueries.deleteImage, imageId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// ReplaceRenditions swaps the Renditions of an Image for rends, so that
+// rendering an Image again leaves no stale Renditions behind.
+func (r *ImageRepositoryMariaDB) ReplaceRenditions(imageId uuid.UUID, rends []Rendition) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		_, err := tx.Exec(imageQueries.deleteRenditions, imageId.String())
+		if err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+
+		for _, rend := range rends {
+			_, err = tx.NamedExec(imageQueries.insertRendition, rend)
+			if err != nil {
+				logger.ErrorWithStack(err)
+				c <- err
+				return
+			}
+		}
+		c <- nil
+	})
+}
+
+// AttachRenditions fetches the Renditions of several Images at once. It is
+// shared by the repositories resolving Images along with their Variants.
+func AttachRenditions(db *sqlx.DB, imgs []Image) (err error) {
+	if len(imgs) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(imgs))
+	for _, img := range imgs {
+		ids = append(ids, img.ImageID.String())
+	}
+
+	query, args, err := sqlx.In(imageQueries.selectRenditions, ids)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	var rends []Rendition
+	err = db.Select(&rends, query, args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	for i := range imgs {
+		imgs[i].AttachRenditions(rends)
+	}
+	return
+}
@@ -0,0 +1,216 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/blob"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+)
+
+// sniffLength is the number of leading bytes http.DetectContentType reads.
+const sniffLength = 512
+
+type ImageService interface {
+	GetImagesByVariantID(variantId uuid.UUID) (imgs []Image, err error)
+	Upload(variantId uuid.UUID, file io.ReadSeeker, size int64, userID uuid.UUID) (img Image, err error)
+	Reorder(variantId uuid.UUID, payload PayloadReorder, userID uuid.UUID) (imgs []Image, err error)
+	Delete(variantId uuid.UUID, imageId uuid.UUID) (err error)
+	MaxUploadSize() int64
+}
+
+type ImageServiceImpl struct {
+	ImageRepository ImageRepository
+	Store           blob.Store
+	PubSub          shared.PubSub
+	Config          *configs.Config
+}
+
+func ProvideImageServiceImpl(imageRepo ImageRepository, store blob.Store, pubsub shared.PubSub, config *configs.Config) *ImageServiceImpl {
+	s := new(ImageServiceImpl)
+	s.ImageRepository = imageRepo
+	s.Store = store
+	s.PubSub = pubsub
+	s.Config = config
+
+	return s
+}
+
+// MaxUploadSize returns the size in bytes uploaded images may not exceed.
+func (s *ImageServiceImpl) MaxUploadSize() int64 {
+	return s.Config.Image.MaxSizeBytes
+}
+
+func (s *ImageServiceImpl) checkVariant(variantId uuid.UUID) (err error) {
+	exists, err := s.ImageRepository.VariantExistsByID(variantId)
+	if err != nil {
+		return
+	}
+	if !exists {
+		err = failure.NotFound("variant")
+	}
+	return
+}
+
+func (s *ImageServiceImpl) GetImagesByVariantID(variantId uuid.UUID) (imgs []Image, err error) {
+	err = s.checkVariant(variantId)
+	if err != nil {
+		return
+	}
+	imgs, err = s.ImageRepository.GetImagesByVariantID(variantId)
+	return
+}
+
+// Upload stores an image in the blob store and appends it to the Images of a
+// Variant. The content type is sniffed from the file rather than trusted from
+// the client. Its Renditions are rendered later by the RenditionWorker.
+func (s *ImageServiceImpl) Upload(variantId uuid.UUID, file io.ReadSeeker, size int64, userID uuid.UUID) (img Image, err error) {
+	if size > s.MaxUploadSize() {
+		err = failure.BadRequestFromString(fmt.Sprintf("image must not exceed %d bytes", s.MaxUploadSize()))
+		return
+	}
+	contentType, err := s.sniffContentType(file)
+	if err != nil {
+		return
+	}
+	err = CheckDimensions(file, s.Config.Image.MaxPixels)
+	if err != nil {
+		return
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = s.checkVariant(variantId)
+	if err != nil {
+		return
+	}
+	position, err := s.ImageRepository.NextPosition(variantId)
+	if err != nil {
+		return
+	}
+
+	img = img.NewUpload(variantId, userID, contentType, size, position)
+	img.ImageURL = s.Store.URL(img.BlobKey.String)
+	err = img.Validate()
+	if err != nil {
+		return
+	}
+
+	err = s.Store.Put(img.BlobKey.String, file, contentType)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = s.ImageRepository.Create(img)
+	if err != nil {
+		s.deleteBlob(img)
+		return
+	}
+
+	img.Renditions = []Rendition{}
+	s.PubSub.Publish(RenditionTopic, []byte(img.ImageID.String()))
+	return
+}
+
+func (s *ImageServiceImpl) sniffContentType(file io.ReadSeeker) (contentType string, err error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		err = failure.BadRequest(err)
+		return
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		err = failure.InternalError(err)
+		return
+	}
+
+	contentType = http.DetectContentType(head[:n])
+	for _, allowed := range s.Config.Image.ContentTypes {
+		if contentType == allowed {
+			return
+		}
+	}
+	err = failure.BadRequestFromString(fmt.Sprintf("content type %s is not one of %s", contentType, strings.Join(s.Config.Image.ContentTypes, ", ")))
+	return
+}
+
+// Reorder moves the Images of a Variant in the order of payload, which must
+// list each of them exactly once.
+func (s *ImageServiceImpl) Reorder(variantId uuid.UUID, payload PayloadReorder, userID uuid.UUID) (imgs []Image, err error) {
+	imgs, err = s.GetImagesByVariantID(variantId)
+	if err != nil {
+		return
+	}
+
+	listed := make(map[uuid.UUID]bool, len(payload.ImageIDs))
+	for _, id := range payload.ImageIDs {
+		listed[id] = true
+	}
+	if len(listed) != len(payload.ImageIDs) || len(listed) != len(imgs) {
+		err = failure.BadRequestFromString("imageIds must list every image of the variant once")
+		return
+	}
+	for _, img := range imgs {
+		if !listed[img.ImageID] {
+			err = failure.BadRequestFromString("imageIds must list every image of the variant once")
+			return
+		}
+	}
+
+	err = s.ImageRepository.Reorder(variantId, payload.ImageIDs, userID)
+	if err != nil {
+		return
+	}
+	imgs, err = s.ImageRepository.GetImagesByVariantID(variantId)
+	return
+}
+
+// Delete removes an Image of a Variant, along with its blobs if it was
+// uploaded.
+func (s *ImageServiceImpl) Delete(variantId uuid.UUID, imageId uuid.UUID) (err error) {
+	img, err := s.ImageRepository.GetImageByID(imageId)
+	if err != nil {
+		return
+	}
+	if img.VariantID != variantId {
+		err = failure.NotFound("image")
+		return
+	}
+
+	err = s.ImageRepository.Delete(imageId)
+	if err != nil {
+		return
+	}
+	s.deleteBlob(img)
+	return
+}
+
+// deleteBlob removes the blobs of an uploaded Image and its Renditions once
+// its row is gone. A failure only leaves orphaned blobs behind, so it is
+// logged rather than returned.
+func (s *ImageServiceImpl) deleteBlob(img Image) {
+	if !img.IsUploaded() {
+		return
+	}
+	keys := []string{img.BlobKey.String}
+	for _, rend := range img.Renditions {
+		keys = append(keys, rend.BlobKey)
+	}
+	for _, key := range keys {
+		if err := s.Store.Delete(key); err != nil {
+			logger.ErrorWithStack(err)
+		}
+	}
+}
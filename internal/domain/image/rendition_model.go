@@ -0,0 +1,147 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	stdimage "image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/chai2010/webp"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+	"golang.org/x/image/draw"
+
+	// Register the decoders of the uploaded content types.
+	_ "image/gif"
+	_ "image/png"
+)
+
+// CheckDimensions reads the dimensions of an encoded image, without decoding
+// it, and fails when it holds more than maxPixels pixels. A small file may
+// claim dimensions whose decoding allocates gigabytes. A non-positive
+// maxPixels sets no limit.
+func CheckDimensions(r io.Reader, maxPixels int64) (err error) {
+	cfg, _, err := stdimage.DecodeConfig(r)
+	if err != nil {
+		return failure.BadRequest(err)
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return failure.BadRequestFromString(fmt.Sprintf("image of %dx%d pixels must not exceed %d pixels", cfg.Width, cfg.Height, maxPixels))
+	}
+	return
+}
+
+// RenditionFormat is the encoding of a Rendition.
+type RenditionFormat string
+
+const (
+	RenditionFormatJPEG RenditionFormat = "jpeg"
+	RenditionFormatWebP RenditionFormat = "webp"
+)
+
+// renditionFormats are the supported Rendition formats, with their content
+// type and file extension.
+var renditionFormats = map[RenditionFormat]struct {
+	contentType string
+	extension   string
+}{
+	RenditionFormatJPEG: {contentType: "image/jpeg", extension: ".jpg"},
+	RenditionFormatWebP: {contentType: "image/webp", extension: ".webp"},
+}
+
+// Rendition is a resized copy of an uploaded Image, rendered in the
+// background once the Image is uploaded.
+type Rendition struct {
+	ImageID   uuid.UUID       `db:"image_id"`
+	Width     int             `db:"width"`
+	Height    int             `db:"height"`
+	Format    RenditionFormat `db:"format"`
+	BlobKey   string          `db:"blob_key"`
+	URL       string          `db:"url"`
+	Size      int64           `db:"size"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+type RenditionResponseFormat struct {
+	Width  int             `json:"width"`
+	Height int             `json:"height"`
+	Format RenditionFormat `json:"format"`
+	URL    string          `json:"url"`
+	Size   int64           `json:"size"`
+}
+
+// ParseRenditionFormat resolves a supported RenditionFormat by name.
+func ParseRenditionFormat(name string) (format RenditionFormat, err error) {
+	format = RenditionFormat(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := renditionFormats[format]; !ok {
+		err = fmt.Errorf("unsupported rendition format %s", name)
+	}
+	return
+}
+
+// ContentType returns the content type of the RenditionFormat.
+func (f RenditionFormat) ContentType() string {
+	return renditionFormats[f].contentType
+}
+
+// NewRendition creates the Rendition of img at width in format. Its blob key
+// sits next to the blob of img.
+func (r Rendition) NewRendition(img Image, width int, height int, format RenditionFormat, size int64) Rendition {
+	key := strings.TrimSuffix(img.BlobKey.String, path.Ext(img.BlobKey.String))
+	return Rendition{
+		ImageID:   img.ImageID,
+		Width:     width,
+		Height:    height,
+		Format:    format,
+		BlobKey:   fmt.Sprintf("%s/%d%s", key, width, renditionFormats[format].extension),
+		Size:      size,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func (r *Rendition) ToResponseFormat() RenditionResponseFormat {
+	return RenditionResponseFormat{
+		Width:  r.Width,
+		Height: r.Height,
+		Format: r.Format,
+		URL:    r.URL,
+		Size:   r.Size,
+	}
+}
+
+// Render scales src down to width, keeping its aspect ratio, and encodes it in
+// format. JPEG has no alpha channel, so transparent areas turn white.
+func Render(src stdimage.Image, width int, format RenditionFormat, quality int) (body []byte, height int, err error) {
+	bounds := src.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		err = fmt.Errorf("cannot render an empty image")
+		return
+	}
+	height = int(math.Max(1, math.Round(float64(bounds.Dy())*float64(width)/float64(bounds.Dx()))))
+
+	dst := stdimage.NewRGBA(stdimage.Rect(0, 0, width, height))
+	op := draw.Src
+	if format == RenditionFormatJPEG {
+		draw.Draw(dst, dst.Bounds(), stdimage.NewUniform(color.White), stdimage.Point{}, draw.Src)
+		op = draw.Over
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, op, nil)
+
+	var buf bytes.Buffer
+	switch format {
+	case RenditionFormatJPEG:
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: quality})
+	case RenditionFormatWebP:
+		err = webp.Encode(&buf, dst, &webp.Options{Quality: float32(quality)})
+	default:
+		err = fmt.Errorf("unsupported rendition format %s", format)
+	}
+	body = buf.Bytes()
+	return
+}
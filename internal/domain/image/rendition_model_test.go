@@ -0,0 +1,67 @@
+package image_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	stdimage "image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/image"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	src := stdimage.NewNRGBA(stdimage.Rect(0, 0, 300, 200))
+	for x := 0; x < 300; x++ {
+		src.Set(x, x%200, color.NRGBA{R: 200, A: 255})
+	}
+
+	for _, format := range []image.RenditionFormat{image.RenditionFormatJPEG, image.RenditionFormatWebP} {
+		body, height, err := image.Render(src, 150, format, 80)
+		assert.NoError(t, err)
+		assert.Equal(t, 100, height)
+
+		decoded, name, err := stdimage.Decode(bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, string(format), name)
+		assert.Equal(t, stdimage.Rect(0, 0, 150, 100), decoded.Bounds())
+	}
+}
+
+func TestNewRendition(t *testing.T) {
+	variantID, _ := uuid.NewV4()
+	userID, _ := uuid.NewV4()
+	img := image.Image{}.NewUpload(variantID, userID, "image/png", 1024, 0)
+
+	rend := image.Rendition{}.NewRendition(img, 480, 320, image.RenditionFormatWebP, 2048)
+	assert.Equal(t, "images/"+variantID.String()+"/"+img.ImageID.String()+"/480.webp", rend.BlobKey)
+	assert.Equal(t, img.ImageID, rend.ImageID)
+
+	_, err := image.ParseRenditionFormat("gif")
+	assert.Error(t, err)
+	format, err := image.ParseRenditionFormat("WebP")
+	assert.NoError(t, err)
+	assert.Equal(t, image.RenditionFormatWebP, format)
+}
+
+func TestCheckDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, stdimage.NewGray(stdimage.Rect(0, 0, 10, 10))))
+	small := buf.Bytes()
+
+	assert.NoError(t, image.CheckDimensions(bytes.NewReader(small), 100))
+	assert.Error(t, image.CheckDimensions(bytes.NewReader(small), 99))
+	assert.Error(t, image.CheckDimensions(bytes.NewReader([]byte("not an image")), 100))
+
+	// A tiny file may claim huge dimensions in its header.
+	bomb := append([]byte(nil), small...)
+	binary.BigEndian.PutUint32(bomb[16:], 50000)
+	binary.BigEndian.PutUint32(bomb[20:], 50000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+	assert.Error(t, image.CheckDimensions(bytes.NewReader(bomb), 40000000))
+	assert.NoError(t, image.CheckDimensions(bytes.NewReader(bomb), 0))
+}
@@ -0,0 +1,147 @@
+package image
+
+import (
+	"bytes"
+	stdimage "image"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/blob"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// RenditionTopic is the PubSub topic carrying the IDs of uploaded Images to
+// render.
+const RenditionTopic = "image.rendition"
+
+// RenditionWorker renders the Renditions of uploaded Images off the request
+// path, on the in-process PubSub worker pool.
+type RenditionWorker struct {
+	ImageRepository ImageRepository
+	Store           blob.Store
+	PubSub          shared.PubSub
+	Config          *configs.Config
+	formats         []RenditionFormat
+}
+
+func ProvideRenditionWorker(imageRepo ImageRepository, store blob.Store, pubsub shared.PubSub, config *configs.Config) *RenditionWorker {
+	return &RenditionWorker{
+		ImageRepository: imageRepo,
+		Store:           store,
+		PubSub:          pubsub,
+		Config:          config,
+	}
+}
+
+// Start subscribes the worker to RenditionTopic. It refuses to run with an
+// unsupported format configured, rather than failing every Image later.
+func (w *RenditionWorker) Start() {
+	w.formats = nil
+	for _, name := range w.Config.Image.Renditions.Formats {
+		format, err := ParseRenditionFormat(name)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid image rendition configuration.")
+		}
+		w.formats = append(w.formats, format)
+	}
+
+	w.PubSub.SubscriberRegistry(RenditionTopic, w.Process,
+		shared.SetMaxRetry(w.Config.Image.Renditions.MaxRetry),
+		shared.SetMaxDelayRetry(time.Second))
+	log.Info().Ints("widths", w.Config.Image.Renditions.Widths).Msg("Image rendition worker started.")
+}
+
+// Process renders the Renditions of the Image whose ID is message. Widths
+// larger than the Image are skipped, as scaling up adds nothing the original
+// does not have.
+func (w *RenditionWorker) Process(message []byte) (err error) {
+	imageId, err := uuid.FromString(string(message))
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return nil
+	}
+	img, err := w.ImageRepository.GetImageByID(imageId)
+	if failure.GetCode(err) == http.StatusNotFound {
+		// The Image was deleted before it could be rendered.
+		return nil
+	}
+	if err != nil || !img.IsUploaded() {
+		return
+	}
+
+	src, err := w.load(img)
+	if failure.GetCode(err) == http.StatusBadRequest {
+		// Rendering the Image again would fail the same way.
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	var rends []Rendition
+	for _, width := range w.Config.Image.Renditions.Widths {
+		if width <= 0 || width > src.Bounds().Dx() {
+			continue
+		}
+		for _, format := range w.formats {
+			var rend Rendition
+			rend, err = w.render(img, src, width, format)
+			if err != nil {
+				return
+			}
+			rends = append(rends, rend)
+		}
+	}
+
+	return w.ImageRepository.ReplaceRenditions(img.ImageID, rends)
+}
+
+func (w *RenditionWorker) load(img Image) (src stdimage.Image, err error) {
+	body, err := w.Store.Get(img.BlobKey.String)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer body.Close()
+
+	// Uploads are bounded in size, so that the file can be read twice: for
+	// its dimensions first, then to decode it.
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = CheckDimensions(bytes.NewReader(content), w.Config.Image.MaxPixels)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	src, _, err = stdimage.Decode(bytes.NewReader(content))
+	if err != nil {
+		err = failure.BadRequest(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (w *RenditionWorker) render(img Image, src stdimage.Image, width int, format RenditionFormat) (rend Rendition, err error) {
+	body, height, err := Render(src, width, format, w.Config.Image.Renditions.Quality)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	rend = rend.NewRendition(img, width, height, format, int64(len(body)))
+	rend.URL = w.Store.URL(rend.BlobKey)
+	err = w.Store.Put(rend.BlobKey, bytes.NewReader(body), format.ContentType())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
@@ -9,7 +9,8 @@ import (
 
 type MaterialRepository interface {
 	Create(payload Material) error
-	GetAll() (mats []Material, err error)
+	GetAll(limit, offset int) (mats []Material, err error)
+	Count() (total int, err error)
 }
 
 type MaterialRepositoryMySQL struct {
@@ -93,8 +94,8 @@ func (r *MaterialRepositoryMariaDB) txCreate(tx *sqlx.Tx, payload Material) (err
 	return
 }
 
-func (r *MaterialRepositoryMariaDB) GetAll() (mats []Material, err error) {
-	err = r.DB.Read.Select(&mats, `select * from materials`)
+func (r *MaterialRepositoryMariaDB) GetAll(limit, offset int) (mats []Material, err error) {
+	err = r.DB.Read.Select(&mats, `select * from materials order by created_at, id limit ? offset ?`, limit, offset)
 
 	if err != nil {
 		err = failure.InternalError(err)
@@ -104,3 +105,13 @@ func (r *MaterialRepositoryMariaDB) GetAll() (mats []Material, err error) {
 	return
 
 }
+
+func (r *MaterialRepositoryMariaDB) Count() (total int, err error) {
+	err = r.DB.Read.Get(&total, `select count(*) from materials`)
+
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
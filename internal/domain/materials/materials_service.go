@@ -2,11 +2,12 @@ package materials
 
 import (
 	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/pagination"
 )
 
 type MaterialService interface {
 	Create(newMat PayloadMaterial) (Material, error)
-	GetAll() (mats []Material, err error)
+	GetAll(pg pagination.Pagination) (mats []Material, total int, err error)
 }
 
 type MaterialServiceImpl struct {
@@ -34,10 +35,11 @@ func (s *MaterialServiceImpl) Create(payload PayloadMaterial) (material Material
 	return
 }
 
-func (s *MaterialServiceImpl) GetAll() (mats []Material, err error) {
-	mats, err = s.MaterialRepository.GetAll()
+func (s *MaterialServiceImpl) GetAll(pg pagination.Pagination) (mats []Material, total int, err error) {
+	mats, err = s.MaterialRepository.GetAll(pg.Limit, pg.Offset)
 	if err != nil {
 		return
 	}
+	total, err = s.MaterialRepository.Count()
 	return
 }
@@ -0,0 +1,262 @@
+package products
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// ExportFormat is the file format of a catalog export.
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// ParseExportFormat reads the format query parameter of an export, which
+// defaults to CSV.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToLower(s)); f {
+	case "":
+		return ExportCSV, nil
+	case ExportCSV, ExportNDJSON:
+		return f, nil
+	}
+	return "", failure.BadRequestFromString("format must be one of csv, ndjson")
+}
+
+// ContentType returns the media type of the format.
+func (f ExportFormat) ContentType() string {
+	if f == ExportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileName returns the name of an export taken at t.
+func (f ExportFormat) FileName(t time.Time) string {
+	return "products-" + t.UTC().Format("20060102T150405Z") + "." + string(f)
+}
+
+// ExportRow is a row of the query joining Products with their live Variants
+// and the Images of those. Products without Variants, and Variants without
+// Images, come as a single row with the missing columns null.
+type ExportRow struct {
+	Product          Product     `db:"product"`
+	VariantID        nuuid.NUUID `db:"variant_id"`
+	VariantName      null.String `db:"variant_name"`
+	Price            null.Float  `db:"price"`
+	Quantity         null.Int    `db:"quantity"`
+	Status           null.String `db:"status"`
+	VariantCreatedAt null.Time   `db:"variant_created_at"`
+	VariantUpdatedAt null.Time   `db:"variant_updated_at"`
+	ImageID          nuuid.NUUID `db:"image_id"`
+	ImageURL         null.String `db:"image_url"`
+	Position         null.Int    `db:"position"`
+}
+
+// ExportProduct is a Product as exported, with its live Variants and their
+// Images.
+type ExportProduct struct {
+	ProductID        uuid.UUID       `json:"productId"`
+	UserID           uuid.UUID       `json:"userId"`
+	BrandID          uuid.UUID       `json:"brandId"`
+	ProductName      string          `json:"productName"`
+	LimitedThreshold null.Int        `json:"limitedThreshold"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	UpdatedAt        time.Time       `json:"updatedAt"`
+	DeletedAt        null.Time       `json:"deletedAt"`
+	Variants         []ExportVariant `json:"variants"`
+}
+
+type ExportVariant struct {
+	VariantID   uuid.UUID     `json:"variantId"`
+	VariantName string        `json:"variantName"`
+	Price       float64       `json:"price"`
+	Quantity    int           `json:"quantity"`
+	Status      string        `json:"status"`
+	Images      []ExportImage `json:"images"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+}
+
+type ExportImage struct {
+	ImageID  uuid.UUID `json:"imageId"`
+	URL      string    `json:"url"`
+	Position int       `json:"position"`
+}
+
+func newExportProduct(prod Product) ExportProduct {
+	return ExportProduct{
+		ProductID:        prod.ProductID,
+		UserID:           prod.UserID,
+		BrandID:          prod.BrandID,
+		ProductName:      prod.ProductName,
+		LimitedThreshold: prod.LimitedThreshold,
+		CreatedAt:        prod.CreatedAt,
+		UpdatedAt:        prod.UpdatedAt,
+		DeletedAt:        prod.DeletedAt,
+		Variants:         []ExportVariant{},
+	}
+}
+
+// add adds the Variant and Image of a row of the Product. Rows come ordered
+// by Variant, so a Variant is only added on its first row.
+func (p *ExportProduct) add(row ExportRow) {
+	if !row.VariantID.Valid {
+		return
+	}
+	last := len(p.Variants) - 1
+	if last < 0 || p.Variants[last].VariantID != row.VariantID.UUID {
+		p.Variants = append(p.Variants, ExportVariant{
+			VariantID:   row.VariantID.UUID,
+			VariantName: row.VariantName.String,
+			Price:       row.Price.Float64,
+			Quantity:    int(row.Quantity.Int64),
+			Status:      row.Status.String,
+			Images:      []ExportImage{},
+			CreatedAt:   row.VariantCreatedAt.Time,
+			UpdatedAt:   row.VariantUpdatedAt.Time,
+		})
+		last++
+	}
+	if row.ImageID.Valid {
+		p.Variants[last].Images = append(p.Variants[last].Images, ExportImage{
+			ImageID:  row.ImageID.UUID,
+			URL:      row.ImageURL.String,
+			Position: int(row.Position.Int64),
+		})
+	}
+}
+
+// exportColumns are the columns of a CSV export, one row per Variant. They
+// include those of an import spreadsheet, so that an export can be edited and
+// imported again.
+var exportColumns = []string{
+	"product_id",
+	importColumnProductName,
+	importColumnBrand,
+	"user_id",
+	importColumnLimitedThreshold,
+	"variant_id",
+	importColumnVariantName,
+	importColumnPrice,
+	importColumnQuantity,
+	importColumnStatus,
+	importColumnImages,
+}
+
+// ExportWriter writes exported Products to a file of its format.
+type ExportWriter interface {
+	Write(prod ExportProduct) error
+	Flush() error
+}
+
+// NewExportWriter returns the ExportWriter of format writing to w.
+func NewExportWriter(format ExportFormat, w io.Writer) ExportWriter {
+	if format == ExportNDJSON {
+		buf := bufio.NewWriter(w)
+		return &ndjsonExportWriter{buf: buf, enc: json.NewEncoder(buf)}
+	}
+	return &csvExportWriter{csv: csv.NewWriter(w)}
+}
+
+// csvExportWriter writes a row per Variant, and a row with empty Variant
+// columns for Products without any.
+type csvExportWriter struct {
+	csv    *csv.Writer
+	header bool
+}
+
+func (cw *csvExportWriter) Write(prod ExportProduct) (err error) {
+	if !cw.header {
+		cw.header = true
+		if err = cw.csv.Write(exportColumns); err != nil {
+			return
+		}
+	}
+
+	threshold := ""
+	if prod.LimitedThreshold.Valid {
+		threshold = strconv.FormatInt(prod.LimitedThreshold.Int64, 10)
+	}
+	record := []string{prod.ProductID.String(), escapeFormula(prod.ProductName), prod.BrandID.String(), prod.UserID.String(), threshold}
+	if len(prod.Variants) == 0 {
+		return cw.csv.Write(append(record, "", "", "", "", "", ""))
+	}
+	for _, vari := range prod.Variants {
+		urls := make([]string, 0, len(vari.Images))
+		for _, img := range vari.Images {
+			urls = append(urls, img.URL)
+		}
+		err = cw.csv.Write(append(record[:len(record):len(record)],
+			vari.VariantID.String(),
+			escapeFormula(vari.VariantName),
+			strconv.FormatFloat(vari.Price, 'f', -1, 64),
+			strconv.Itoa(vari.Quantity),
+			vari.Status,
+			escapeFormula(strings.Join(urls, "|")),
+		))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// formulaPrefixes are the first characters making spreadsheets read a cell
+// as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeFormula prefixes user input read as a formula by spreadsheets with a
+// quote, making it plain text. ParseImportRows drops the quote again.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune(formulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeFormula undoes escapeFormula.
+func unescapeFormula(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// Flush writes the header of an empty export as well.
+func (cw *csvExportWriter) Flush() error {
+	if !cw.header {
+		cw.header = true
+		if err := cw.csv.Write(exportColumns); err != nil {
+			return err
+		}
+	}
+	cw.csv.Flush()
+	return cw.csv.Error()
+}
+
+// ndjsonExportWriter writes a JSON document per Product and line.
+type ndjsonExportWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (nw *ndjsonExportWriter) Write(prod ExportProduct) error {
+	return nw.enc.Encode(prod)
+}
+
+func (nw *ndjsonExportWriter) Flush() error {
+	return nw.buf.Flush()
+}
@@ -0,0 +1,81 @@
+package products_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/products"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportWriter(t *testing.T) {
+	prodId := uuid.FromStringOrNil("6f1b1c1e-0000-4000-8000-000000000001")
+	variantId := uuid.FromStringOrNil("6f1b1c1e-0000-4000-8000-000000000002")
+	prods := []products.ExportProduct{
+		{
+			ProductID:   prodId,
+			ProductName: "Shirt",
+			Variants: []products.ExportVariant{{
+				VariantID:   variantId,
+				VariantName: "Blue, dark",
+				Price:       10.5,
+				Quantity:    3,
+				Status:      "ready",
+				Images:      []products.ExportImage{{URL: "https://a.test/1.jpg"}, {URL: "https://a.test/2.jpg"}},
+			}},
+		},
+		{ProductID: prodId, ProductName: "Hat"},
+	}
+
+	var buf bytes.Buffer
+	w := products.NewExportWriter(products.ExportCSV, &buf)
+	for _, prod := range prods {
+		assert.NoError(t, w.Write(prod))
+	}
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "product_id,product_name,brand,user_id,limited_threshold,variant_id,variant_name,price,quantity,status,images\n"+
+		prodId.String()+",Shirt,"+uuid.Nil.String()+","+uuid.Nil.String()+","+
+		","+variantId.String()+`,"Blue, dark",10.5,3,ready,https://a.test/1.jpg|https://a.test/2.jpg`+"\n"+
+		prodId.String()+",Hat,"+uuid.Nil.String()+","+uuid.Nil.String()+",,,,,,,\n", buf.String())
+
+	buf.Reset()
+	w = products.NewExportWriter(products.ExportNDJSON, &buf)
+	for _, prod := range prods {
+		assert.NoError(t, w.Write(prod))
+	}
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	t.Run("escapes formulas in CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := products.NewExportWriter(products.ExportCSV, &buf)
+		assert.NoError(t, w.Write(products.ExportProduct{ProductName: "=HYPERLINK(\"http://evil.test\")", Variants: []products.ExportVariant{{VariantName: "@SUM(A1)"}}}))
+		assert.NoError(t, w.Flush())
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, `'=HYPERLINK("http://evil.test")`, rows[1][1])
+		assert.Equal(t, "'@SUM(A1)", rows[1][6])
+
+		parsed, errs, err := products.ParseImportRows(rows)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, `=HYPERLINK("http://evil.test")`, parsed[0].Payload.ProductName)
+		assert.Equal(t, "@SUM(A1)", parsed[0].Payload.VariantPayload.VariantName)
+	})
+}
+
+func TestParseExportFormat(t *testing.T) {
+	format, err := products.ParseExportFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, products.ExportCSV, format)
+
+	format, err = products.ParseExportFormat("NDJSON")
+	assert.NoError(t, err)
+	assert.Equal(t, products.ExportNDJSON, format)
+
+	_, err = products.ParseExportFormat("xml")
+	assert.Error(t, err)
+}
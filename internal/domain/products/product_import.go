@@ -0,0 +1,546 @@
+package products
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/internal/domain/image"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/spreadsheet"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// ImportStatus is the progress of a ProductImport.
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportSucceeded ImportStatus = "succeeded"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// The columns of an import spreadsheet, named by its header row. Each row is
+// a Variant of the Product named by the row, brand being the ID or the name
+// of the Brand of the Product and images a list of URLs separated by |.
+const (
+	importColumnProductName      = "product_name"
+	importColumnBrand            = "brand"
+	importColumnLimitedThreshold = "limited_threshold"
+	importColumnVariantName      = "variant_name"
+	importColumnPrice            = "price"
+	importColumnQuantity         = "quantity"
+	importColumnStatus           = "status"
+	importColumnImages           = "images"
+)
+
+var requiredImportColumns = []string{
+	importColumnProductName,
+	importColumnBrand,
+	importColumnVariantName,
+	importColumnPrice,
+	importColumnQuantity,
+}
+
+// ProductImport is a background job importing Products and their Variants
+// from a spreadsheet kept in the blob store. Products are matched with those
+// of the importing user by brand and name, and Variants by name, so that
+// importing a spreadsheet again updates what it created. Dry runs validate the
+// rows and count what would be written without writing anything.
+type ProductImport struct {
+	ImportID        uuid.UUID          `db:"import_id"`
+	UserID          uuid.UUID          `db:"user_id"`
+	FileName        string             `db:"file_name"`
+	Format          spreadsheet.Format `db:"format"`
+	BlobKey         string             `db:"blob_key"`
+	DryRun          bool               `db:"dry_run"`
+	Status          ImportStatus       `db:"status"`
+	Message         null.String        `db:"message"`
+	TotalRows       int                `db:"total_rows"`
+	FailedRows      int                `db:"failed_rows"`
+	CreatedProducts int                `db:"created_products"`
+	UpdatedProducts int                `db:"updated_products"`
+	CreatedVariants int                `db:"created_variants"`
+	UpdatedVariants int                `db:"updated_variants"`
+	Errors          []ImportError      `db:"-"`
+	CreatedAt       time.Time          `db:"created_at"`
+	UpdatedAt       time.Time          `db:"updated_at"`
+	FinishedAt      null.Time          `db:"finished_at"`
+}
+
+// ImportError tells why a row of a ProductImport was rejected. Line is the
+// line of the row in the spreadsheet, the header being line 1.
+type ImportError struct {
+	ImportID uuid.UUID `db:"import_id"`
+	Line     int       `db:"line"`
+	Message  string    `db:"message"`
+}
+
+type ImportErrorResponseFormat struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type ProductImportResponseFormat struct {
+	ImportID        uuid.UUID                   `json:"importId"`
+	UserID          uuid.UUID                   `json:"userId"`
+	FileName        string                      `json:"fileName"`
+	Format          spreadsheet.Format          `json:"format"`
+	DryRun          bool                        `json:"dryRun"`
+	Status          ImportStatus                `json:"status"`
+	Message         null.String                 `json:"message"`
+	TotalRows       int                         `json:"totalRows"`
+	FailedRows      int                         `json:"failedRows"`
+	CreatedProducts int                         `json:"createdProducts"`
+	UpdatedProducts int                         `json:"updatedProducts"`
+	CreatedVariants int                         `json:"createdVariants"`
+	UpdatedVariants int                         `json:"updatedVariants"`
+	Errors          []ImportErrorResponseFormat `json:"errors"`
+	CreatedAt       time.Time                   `json:"createdAt"`
+	UpdatedAt       time.Time                   `json:"updatedAt"`
+	FinishedAt      null.Time                   `json:"finishedAt"`
+}
+
+// NewFromUpload creates a pending ProductImport of the uploaded file fileName,
+// whose extension tells its format.
+func (pi ProductImport) NewFromUpload(fileName string, dryRun bool, userID uuid.UUID) (ProductImport, error) {
+	format, err := spreadsheet.FormatOf(fileName)
+	if err != nil {
+		return ProductImport{}, failure.BadRequest(err)
+	}
+
+	importId, _ := uuid.NewV4()
+	return ProductImport{
+		ImportID:  importId,
+		UserID:    userID,
+		FileName:  path.Base(fileName),
+		Format:    format,
+		BlobKey:   path.Join("imports", importId.String()+"."+string(format)),
+		DryRun:    dryRun,
+		Status:    ImportPending,
+		Errors:    []ImportError{},
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Start marks the ProductImport as running. Only pending imports are started,
+// so that a redelivered job is not run twice.
+func (pi *ProductImport) Start() (err error) {
+	if pi.Status != ImportPending {
+		return failure.Conflict("start", "ProductImport", "is already "+string(pi.Status))
+	}
+	pi.Status = ImportRunning
+	pi.UpdatedAt = time.Now().UTC()
+	return
+}
+
+// Fail marks the ProductImport as failed as a whole, such as when its file
+// cannot be read.
+func (pi *ProductImport) Fail(err error) {
+	pi.Status = ImportFailed
+	pi.Message = null.StringFrom(errorMessage(err))
+	pi.finish()
+}
+
+// Succeed marks the ProductImport as done. Some of its rows may still have
+// been rejected.
+func (pi *ProductImport) Succeed() {
+	pi.Status = ImportSucceeded
+	pi.finish()
+}
+
+func (pi *ProductImport) finish() {
+	lines := make(map[int]bool, len(pi.Errors))
+	for _, e := range pi.Errors {
+		lines[e.Line] = true
+	}
+	pi.FailedRows = len(lines)
+	pi.UpdatedAt = time.Now().UTC()
+	pi.FinishedAt = null.TimeFrom(pi.UpdatedAt)
+}
+
+// reject reports why the rows at lines were not imported.
+func (pi *ProductImport) reject(err error, lines ...int) {
+	for _, line := range lines {
+		pi.Errors = append(pi.Errors, ImportError{ImportID: pi.ImportID, Line: line, Message: errorMessage(err)})
+	}
+}
+
+// errorMessage returns the message of a Failure without its status text.
+func errorMessage(err error) string {
+	if f, ok := err.(*failure.Failure); ok {
+		return f.Message
+	}
+	return err.Error()
+}
+
+func (pi *ProductImport) ToResponseFormat() ProductImportResponseFormat {
+	errs := make([]ImportErrorResponseFormat, 0, len(pi.Errors))
+	for _, e := range pi.Errors {
+		errs = append(errs, ImportErrorResponseFormat{Line: e.Line, Message: e.Message})
+	}
+	return ProductImportResponseFormat{
+		ImportID:        pi.ImportID,
+		UserID:          pi.UserID,
+		FileName:        pi.FileName,
+		Format:          pi.Format,
+		DryRun:          pi.DryRun,
+		Status:          pi.Status,
+		Message:         pi.Message,
+		TotalRows:       pi.TotalRows,
+		FailedRows:      pi.FailedRows,
+		CreatedProducts: pi.CreatedProducts,
+		UpdatedProducts: pi.UpdatedProducts,
+		CreatedVariants: pi.CreatedVariants,
+		UpdatedVariants: pi.UpdatedVariants,
+		Errors:          errs,
+		CreatedAt:       pi.CreatedAt,
+		UpdatedAt:       pi.UpdatedAt,
+		FinishedAt:      pi.FinishedAt,
+	}
+}
+
+func (pi ProductImport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pi.ToResponseFormat())
+}
+
+// ImportRow is a row of an import spreadsheet. BrandID is left to be resolved
+// from Brand.
+type ImportRow struct {
+	Line    int
+	Brand   string
+	Payload PayloadProductAndVariant
+}
+
+// key identifies the Product named by the row among those of a user.
+func (row ImportRow) key(brandId uuid.UUID) string {
+	return brandId.String() + "/" + strings.ToLower(strings.TrimSpace(row.Payload.ProductName))
+}
+
+// ParseImportRows reads the rows of an import spreadsheet by the names of its
+// header row. Blank rows are skipped and rows with unreadable cells rejected;
+// a header lacking required columns fails the whole import.
+func ParseImportRows(rows [][]string) (parsed []ImportRow, errs []ImportError, err error) {
+	if len(rows) == 0 {
+		err = failure.BadRequestFromString("spreadsheet is empty")
+		return
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []string
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		err = failure.BadRequestFromString("spreadsheet lacks the columns " + strings.Join(missing, ", "))
+		return
+	}
+
+	for i, cells := range rows[1:] {
+		line := i + 2
+		cell := func(name string) string {
+			col, ok := columns[name]
+			if !ok || col >= len(cells) {
+				return ""
+			}
+			return unescapeFormula(strings.TrimSpace(cells[col]))
+		}
+		if strings.TrimSpace(strings.Join(cells, "")) == "" {
+			continue
+		}
+
+		row, rowErr := parseImportRow(cell)
+		if rowErr != nil {
+			errs = append(errs, ImportError{Line: line, Message: rowErr.Error()})
+			continue
+		}
+		row.Line = line
+		parsed = append(parsed, row)
+	}
+	return
+}
+
+func parseImportRow(cell func(name string) string) (row ImportRow, err error) {
+	row.Brand = cell(importColumnBrand)
+	row.Payload.ProductName = cell(importColumnProductName)
+	row.Payload.VariantPayload.VariantName = cell(importColumnVariantName)
+	row.Payload.VariantPayload.Status = cell(importColumnStatus)
+
+	if threshold := cell(importColumnLimitedThreshold); threshold != "" {
+		t, err := strconv.Atoi(threshold)
+		if err != nil {
+			return row, fmt.Errorf("%s must be a whole number", importColumnLimitedThreshold)
+		}
+		row.Payload.LimitedThreshold = &t
+	}
+	row.Payload.VariantPayload.Price, err = strconv.ParseFloat(cell(importColumnPrice), 64)
+	if err != nil {
+		return row, fmt.Errorf("%s must be a number", importColumnPrice)
+	}
+	row.Payload.VariantPayload.Quantity, err = strconv.Atoi(cell(importColumnQuantity))
+	if err != nil {
+		return row, fmt.Errorf("%s must be a whole number", importColumnQuantity)
+	}
+	for _, url := range strings.Split(cell(importColumnImages), "|") {
+		if url = strings.TrimSpace(url); url != "" {
+			row.Payload.VariantPayload.ImagePayload = append(row.Payload.VariantPayload.ImagePayload, url)
+		}
+	}
+
+	if row.Brand == "" {
+		return row, fmt.Errorf("%s is required", importColumnBrand)
+	}
+	if row.Payload.VariantPayload.VariantName == "" {
+		return row, fmt.Errorf("%s is required", importColumnVariantName)
+	}
+	return row, nil
+}
+
+// ImportBrand is a Brand rows of an import may refer to by ID or by name.
+type ImportBrand struct {
+	BrandID   uuid.UUID `db:"brand_id"`
+	BrandName string    `db:"brand_name"`
+}
+
+// resolveImportBrand finds the Brand a row refers to by ID or else by name,
+// names being compared case insensitively.
+func resolveImportBrand(brands []ImportBrand, ref string) (brandId uuid.UUID, err error) {
+	if id, idErr := uuid.FromString(ref); idErr == nil {
+		for _, b := range brands {
+			if b.BrandID == id {
+				return id, nil
+			}
+		}
+		return uuid.Nil, failure.NotFound("brand " + ref)
+	}
+
+	found := 0
+	for _, b := range brands {
+		if strings.EqualFold(b.BrandName, ref) {
+			brandId = b.BrandID
+			found++
+		}
+	}
+	switch found {
+	case 0:
+		err = failure.NotFound("brand " + ref)
+	case 1:
+	default:
+		err = failure.BadRequestFromString("several brands are named " + ref + ", refer to the brand by its ID")
+	}
+	return
+}
+
+// importGroup is the rows of an import naming the same Product.
+type importGroup struct {
+	key     string
+	brandId uuid.UUID
+	rows    []ImportRow
+}
+
+func (g importGroup) lines() []int {
+	lines := make([]int, 0, len(g.rows))
+	for _, row := range g.rows {
+		lines = append(lines, row.Line)
+	}
+	return lines
+}
+
+// ImportBatch is the writes of a batch of import rows, committed in a single
+// transaction.
+type ImportBatch struct {
+	NewProducts     []Product
+	UpdatedProducts []Product
+	NewVariants     []variants.Variant
+	UpdatedVariants []variants.Variant
+	NewImages       []image.Image
+	GlobalThreshold int
+}
+
+// IsEmpty reports whether the batch writes nothing.
+func (b *ImportBatch) IsEmpty() bool {
+	return len(b.NewProducts) == 0 && len(b.UpdatedProducts) == 0 && len(b.NewVariants) == 0 &&
+		len(b.UpdatedVariants) == 0 && len(b.NewImages) == 0
+}
+
+// importCounts are what a batch creates and updates, added to the
+// ProductImport once the batch is written.
+type importCounts struct {
+	createdProducts, updatedProducts, createdVariants, updatedVariants int
+}
+
+func (pi *ProductImport) add(c importCounts) {
+	pi.CreatedProducts += c.createdProducts
+	pi.UpdatedProducts += c.updatedProducts
+	pi.CreatedVariants += c.createdVariants
+	pi.UpdatedVariants += c.updatedVariants
+}
+
+// planImportGroup adds the writes importing a group to batch, existing being
+// the Product the group matches, if any, and returns the lines of the rows it
+// planned. The rows are validated the way the product and variant endpoints
+// validate their payloads, and rejected rows are reported on the
+// ProductImport. The Product takes the limited threshold of the first row
+// setting one.
+func (pi *ProductImport) planImportGroup(g importGroup, existing *ProductWithVariants, batch *ImportBatch, counts *importCounts) (planned []int) {
+	validator := shared.GetValidator()
+
+	var rows []ImportRow
+	for _, row := range g.rows {
+		row.Payload.BrandID = g.brandId
+		if err := validator.Struct(row.Payload); err != nil {
+			pi.reject(err, row.Line)
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	var threshold *int
+	for _, row := range rows {
+		if row.Payload.LimitedThreshold != nil {
+			threshold = row.Payload.LimitedThreshold
+			break
+		}
+	}
+
+	var prod Product
+	var current []variants.Variant
+	thresholdChanged := false
+	if existing != nil {
+		prod = existing.Product
+		current = existing.Variants
+		if threshold != nil && (!prod.LimitedThreshold.Valid || prod.LimitedThreshold.Int64 != int64(*threshold)) {
+			prod.LimitedThreshold = null.IntFrom(int64(*threshold))
+			prod.UpdatedAt = time.Now().UTC()
+			prod.UpdatedBy = pi.UserID
+			thresholdChanged = true
+		}
+	} else {
+		var err error
+		prod, err = prod.NewFromPayload(PayloadProduct{
+			BrandID:          g.brandId,
+			ProductName:      rows[0].Payload.ProductName,
+			LimitedThreshold: threshold,
+		}, pi.UserID)
+		if err != nil {
+			pi.reject(err, g.lines()...)
+			return
+		}
+	}
+	limitedThreshold := prod.limitedThreshold(batch.GlobalThreshold)
+
+	var newVariants, updatedVariants []variants.Variant
+	var newImages []image.Image
+	seen := make(map[string]int)
+	for _, row := range rows {
+		name := strings.ToLower(row.Payload.VariantPayload.VariantName)
+		if line, ok := seen[name]; ok {
+			pi.reject(fmt.Errorf("variant %s is already imported by line %d", row.Payload.VariantPayload.VariantName, line), row.Line)
+			continue
+		}
+		seen[name] = row.Line
+
+		vari, imgs, exists, err := planImportVariant(row, prod.ProductID, current, pi.UserID, limitedThreshold)
+		if err != nil {
+			pi.reject(err, row.Line)
+			continue
+		}
+		planned = append(planned, row.Line)
+		if exists {
+			updatedVariants = append(updatedVariants, vari)
+			newImages = append(newImages, imgs...)
+		} else {
+			newVariants = append(newVariants, vari)
+		}
+	}
+	if len(newVariants) == 0 && len(updatedVariants) == 0 {
+		return
+	}
+
+	if existing == nil {
+		batch.NewProducts = append(batch.NewProducts, prod)
+		counts.createdProducts++
+	} else {
+		if thresholdChanged {
+			batch.UpdatedProducts = append(batch.UpdatedProducts, prod)
+		}
+		counts.updatedProducts++
+	}
+	batch.NewVariants = append(batch.NewVariants, newVariants...)
+	batch.UpdatedVariants = append(batch.UpdatedVariants, updatedVariants...)
+	batch.NewImages = append(batch.NewImages, newImages...)
+	counts.createdVariants += len(newVariants)
+	counts.updatedVariants += len(updatedVariants)
+	return
+}
+
+// planImportVariant creates the Variant a row names, or updates it when it is
+// among current. Updated Variants keep their images and gain those of the row
+// they lack.
+func planImportVariant(row ImportRow, prodId uuid.UUID, current []variants.Variant, userID uuid.UUID, limitedThreshold int) (vari variants.Variant, newImages []image.Image, exists bool, err error) {
+	payload := row.Payload.VariantPayload
+	for _, c := range current {
+		if strings.EqualFold(c.VariantName, payload.VariantName) {
+			vari, exists = c, true
+			break
+		}
+	}
+	if !exists {
+		vari, err = vari.NewFromPayload(payload, prodId, userID, limitedThreshold)
+		return
+	}
+
+	update := variants.PayloadUpdateVariant{
+		VariantName: payload.VariantName,
+		Price:       payload.Price,
+		Status:      payload.Status,
+		Quantity:    payload.Quantity,
+	}
+	err = shared.GetValidator().Struct(update)
+	if err != nil {
+		return
+	}
+	err = vari.Update(update, userID, limitedThreshold)
+	if err != nil {
+		return
+	}
+
+	position := 0
+	urls := make(map[string]bool, len(vari.Images))
+	for _, img := range vari.Images {
+		urls[img.ImageURL] = true
+		if img.Position >= position {
+			position = img.Position + 1
+		}
+	}
+	for _, url := range payload.ImagePayload {
+		if urls[url] {
+			continue
+		}
+		var img image.Image
+		img, err = img.NewFromPayload(url, vari.VariantID, userID, position)
+		if err != nil {
+			return
+		}
+		urls[url] = true
+		newImages = append(newImages, img)
+		position++
+	}
+	return
+}
@@ -0,0 +1,275 @@
+package products
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/spreadsheet"
+	"github.com/gofrs/uuid"
+)
+
+// ImportTopic is the PubSub topic carrying the IDs of the ProductImports to
+// run.
+const ImportTopic = "product.import"
+
+// MaxImportSize returns the size in bytes import spreadsheets may not exceed.
+func (s *ProductServiceImpl) MaxImportSize() int64 {
+	return s.Config.Import.MaxSizeBytes
+}
+
+// StartImport keeps an uploaded spreadsheet in the blob store and queues its
+// import, which runs in the background.
+func (s *ProductServiceImpl) StartImport(file io.ReadSeeker, fileName string, size int64, dryRun bool, userID uuid.UUID) (imp ProductImport, err error) {
+	err = s.checkUser(userID)
+	if err != nil {
+		return
+	}
+	if size > s.MaxImportSize() {
+		err = failure.BadRequestFromString(fmt.Sprintf("spreadsheet must not exceed %d bytes", s.MaxImportSize()))
+		return
+	}
+	imp, err = imp.NewFromUpload(fileName, dryRun, userID)
+	if err != nil {
+		return
+	}
+
+	err = s.Store.Put(imp.BlobKey, file, imp.Format.ContentType())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = s.ProductRepository.CreateImport(imp)
+	if err != nil {
+		s.deleteImportFile(imp)
+		return
+	}
+
+	s.PubSub.Publish(ImportTopic, []byte(imp.ImportID.String()))
+	return
+}
+
+func (s *ProductServiceImpl) GetImportByID(importId uuid.UUID) (imp ProductImport, err error) {
+	return s.ProductRepository.GetImportByID(importId)
+}
+
+// GetImportOwner returns the ID of the user who started a ProductImport.
+func (s *ProductServiceImpl) GetImportOwner(importId uuid.UUID) (ownerID uuid.UUID, err error) {
+	imp, err := s.ProductRepository.GetImportByID(importId)
+	if err != nil {
+		return
+	}
+	ownerID = imp.UserID
+	return
+}
+
+// RunImport runs a pending ProductImport and records its outcome. Its
+// spreadsheet is deleted once it is done.
+func (s *ProductServiceImpl) RunImport(importId uuid.UUID) (err error) {
+	imp, err := s.ProductRepository.GetImportByID(importId)
+	if err != nil {
+		return
+	}
+	err = imp.Start()
+	if err == nil {
+		err = s.ProductRepository.ClaimImport(imp)
+	}
+	if failure.GetCode(err) == http.StatusConflict {
+		// The import was run already, or is being run.
+		logger.ErrorWithStack(err)
+		return nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = s.runImport(&imp)
+	if err != nil {
+		imp.Fail(err)
+	} else {
+		imp.Succeed()
+	}
+	err = s.ProductRepository.FinishImport(imp)
+	s.deleteImportFile(imp)
+	return
+}
+
+// ResumeImports queues the imports left pending when the process stopped,
+// along with those left running for longer than the configured delay, which
+// are run again from the start. Imports match what they already wrote, so
+// running one again does not duplicate it.
+func (s *ProductServiceImpl) ResumeImports() (resumed int, err error) {
+	staleAfter := time.Duration(s.Config.Import.StaleAfterSeconds) * time.Second
+	err = s.ProductRepository.RequeueImports(time.Now().UTC().Add(-staleAfter))
+	if err != nil {
+		return
+	}
+
+	importIds, err := s.ProductRepository.GetPendingImportIDs()
+	if err != nil {
+		return
+	}
+	for _, importId := range importIds {
+		s.PubSub.Publish(ImportTopic, []byte(importId.String()))
+	}
+	return len(importIds), nil
+}
+
+// runImport imports the rows of a spreadsheet in batches of whole Products,
+// each written in its own transaction. A batch failing to be written rejects
+// its rows without stopping the import.
+func (s *ProductServiceImpl) runImport(imp *ProductImport) (err error) {
+	rows, err := s.readImportFile(*imp)
+	if err != nil {
+		return
+	}
+	parsed, errs, err := ParseImportRows(rows)
+	if err != nil {
+		return
+	}
+	imp.TotalRows = len(parsed) + len(errs)
+	imp.Errors = append(imp.Errors, errs...)
+
+	groups, err := s.groupImportRows(imp, parsed)
+	if err != nil {
+		return
+	}
+
+	for _, batch := range batchImportGroups(groups, s.Config.Import.BatchSize) {
+		err = s.importBatch(imp, batch)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (s *ProductServiceImpl) readImportFile(imp ProductImport) (rows [][]string, err error) {
+	body, err := s.Store.Get(imp.BlobKey)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer body.Close()
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	rows, err = spreadsheet.Read(bytes.NewReader(content), int64(len(content)), imp.Format)
+	if err != nil {
+		err = failure.BadRequest(err)
+	}
+	return
+}
+
+// groupImportRows resolves the Brands of the rows and groups the rows by the
+// Product they name, in the order Products first appear.
+func (s *ProductServiceImpl) groupImportRows(imp *ProductImport, rows []ImportRow) (groups []importGroup, err error) {
+	refs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if !seen[row.Brand] {
+			seen[row.Brand] = true
+			refs = append(refs, row.Brand)
+		}
+	}
+	brands, err := s.ProductRepository.GetImportBrands(refs)
+	if err != nil {
+		return
+	}
+
+	index := make(map[string]int)
+	for _, row := range rows {
+		brandId, brandErr := resolveImportBrand(brands, row.Brand)
+		if brandErr != nil {
+			imp.reject(brandErr, row.Line)
+			continue
+		}
+
+		key := row.key(brandId)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, importGroup{key: key, brandId: brandId})
+		}
+		groups[i].rows = append(groups[i].rows, row)
+	}
+	return
+}
+
+// batchImportGroups splits groups into batches of about size rows. A Product
+// is never split across batches.
+func batchImportGroups(groups []importGroup, size int) (batches [][]importGroup) {
+	var batch []importGroup
+	rows := 0
+	for _, g := range groups {
+		if len(batch) > 0 && rows+len(g.rows) > size {
+			batches = append(batches, batch)
+			batch, rows = nil, 0
+		}
+		batch = append(batch, g)
+		rows += len(g.rows)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return
+}
+
+// importBatch matches a batch of groups with the existing Products of the
+// importing user, then writes them unless the import is a dry run.
+func (s *ProductServiceImpl) importBatch(imp *ProductImport, groups []importGroup) (err error) {
+	keys := make([]ImportKey, 0, len(groups))
+	for _, g := range groups {
+		keys = append(keys, ImportKey{BrandID: g.brandId, ProductName: strings.TrimSpace(g.rows[0].Payload.ProductName)})
+	}
+	targets, err := s.ProductRepository.GetImportTargets(imp.UserID, keys)
+	if err != nil {
+		return
+	}
+
+	batch := ImportBatch{GlobalThreshold: s.Config.Variant.LimitedThreshold}
+	var counts importCounts
+	var planned []int
+	for _, g := range groups {
+		planned = append(planned, imp.planImportGroup(g, findImportTarget(targets, g), &batch, &counts)...)
+	}
+
+	if imp.DryRun || batch.IsEmpty() {
+		imp.add(counts)
+		return
+	}
+	if writeErr := s.ProductRepository.ImportBatch(batch); writeErr != nil {
+		imp.reject(writeErr, planned...)
+		return
+	}
+	imp.add(counts)
+	return
+}
+
+// findImportTarget returns the existing Product a group names, if any.
+func findImportTarget(targets []ProductWithVariants, g importGroup) *ProductWithVariants {
+	for i := range targets {
+		if targets[i].Product.BrandID == g.brandId &&
+			strings.EqualFold(strings.TrimSpace(targets[i].Product.ProductName), strings.TrimSpace(g.rows[0].Payload.ProductName)) {
+			return &targets[i]
+		}
+	}
+	return nil
+}
+
+func (s *ProductServiceImpl) deleteImportFile(imp ProductImport) {
+	if err := s.Store.Delete(imp.BlobKey); err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
@@ -0,0 +1,33 @@
+package products_test
+
+import (
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/products"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImportRows(t *testing.T) {
+	parsed, errs, err := products.ParseImportRows([][]string{
+		{"Product_Name", "brand", "variant_name", "price", "quantity", "images"},
+		{"Shirt", "Shirtworks", "Blue", "10.5", "3", "https://a.test/1.jpg | https://a.test/2.jpg"},
+		{"", "", "", "", ""},
+		{"Shirt", "Shirtworks", "Red", "cheap", "3"},
+		{"Shirt", "", "Green", "10", "3"},
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, parsed, 1) {
+		assert.Equal(t, 2, parsed[0].Line)
+		assert.Equal(t, "Shirtworks", parsed[0].Brand)
+		assert.Equal(t, 10.5, parsed[0].Payload.VariantPayload.Price)
+		assert.Equal(t, []string{"https://a.test/1.jpg", "https://a.test/2.jpg"}, parsed[0].Payload.VariantPayload.ImagePayload)
+	}
+	if assert.Len(t, errs, 2) {
+		assert.Equal(t, products.ImportError{Line: 4, Message: "price must be a number"}, errs[0])
+		assert.Equal(t, products.ImportError{Line: 5, Message: "brand is required"}, errs[1])
+	}
+
+	_, _, err = products.ParseImportRows([][]string{{"product_name", "brand"}})
+	assert.Error(t, err)
+}
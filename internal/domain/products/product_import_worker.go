@@ -0,0 +1,45 @@
+package products
+
+import (
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// ImportWorker runs ProductImports off the request path, on the in-process
+// PubSub worker pool.
+type ImportWorker struct {
+	ProductService ProductService
+	PubSub         shared.PubSub
+}
+
+func ProvideImportWorker(productService ProductService, pubsub shared.PubSub) *ImportWorker {
+	return &ImportWorker{
+		ProductService: productService,
+		PubSub:         pubsub,
+	}
+}
+
+// Start subscribes the worker to ImportTopic, then queues the imports a
+// previous process left behind, as the in-process PubSub lost them.
+func (w *ImportWorker) Start() {
+	w.PubSub.SubscriberRegistry(ImportTopic, w.Process)
+
+	resumed, err := w.ProductService.ResumeImports()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	log.Info().Int("resumed", resumed).Msg("Product import worker started.")
+}
+
+// Process runs the ProductImport whose ID is message. A redelivered message
+// finds the import already started and does nothing.
+func (w *ImportWorker) Process(message []byte) (err error) {
+	importId, err := uuid.FromString(string(message))
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return nil
+	}
+	return w.ProductService.RunImport(importId)
+}
@@ -2,53 +2,108 @@ package products
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/evermos/boilerplate-go/internal/domain/variants"
 	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/evermos/boilerplate-go/shared/pagination"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
 )
 
+// Product is a sellable item. LimitedThreshold, when set, overrides the global
+// threshold under which the Variants of the Product are limited.
 type Product struct {
-	ProductID   uuid.UUID   `db:"product_id" validate:"required"`
-	UserID      uuid.UUID   `db:"user_id" validate:"required"`
-	BrandID     uuid.UUID   `db:"brand_id" validate:"required"`
-	ProductName string      `db:"product_name" validate:"required"`
-	CreatedAt   time.Time   `db:"created_at" validate:"required"`
-	UpdatedAt   time.Time   `db:"updated_at" validate:"required"`
-	DeletedAt   null.Time   `db:"deleted_at"`
-	CreatedBy   uuid.UUID   `db:"created_by" validate:"required"`
-	UpdatedBy   uuid.UUID   `db:"updated_by" validate:"required"`
-	DeletedBy   nuuid.NUUID `db:"deleted_by"`
+	ProductID        uuid.UUID   `db:"product_id" validate:"required"`
+	UserID           uuid.UUID   `db:"user_id" validate:"required"`
+	BrandID          uuid.UUID   `db:"brand_id" validate:"required"`
+	ProductName      string      `db:"product_name" validate:"required"`
+	LimitedThreshold null.Int    `db:"limited_threshold"`
+	CreatedAt        time.Time   `db:"created_at" validate:"required"`
+	UpdatedAt        time.Time   `db:"updated_at" validate:"required"`
+	DeletedAt        null.Time   `db:"deleted_at"`
+	CreatedBy        uuid.UUID   `db:"created_by" validate:"required"`
+	UpdatedBy        uuid.UUID   `db:"updated_by" validate:"required"`
+	DeletedBy        nuuid.NUUID `db:"deleted_by"`
+}
+
+// ProductFields are the fields Products can be sorted and filtered by.
+var ProductFields = pagination.Fields{
+	"productId":   "product_id",
+	"productName": "product_name",
+	"brandId":     "brand_id",
+	"userId":      "user_id",
+	"createdAt":   "created_at",
+	"updatedAt":   "updated_at",
+}
+
+// productCursorValues reads the columns of ProductFields, formatted the way
+// they compare in SQL, to build keyset pagination cursors.
+var productCursorValues = map[string]func(p Product) string{
+	"product_id":   func(p Product) string { return p.ProductID.String() },
+	"product_name": func(p Product) string { return p.ProductName },
+	"brand_id":     func(p Product) string { return p.BrandID.String() },
+	"user_id":      func(p Product) string { return p.UserID.String() },
+	"created_at":   func(p Product) string { return p.CreatedAt.UTC().Format(sqlTimeFormat) },
+	"updated_at":   func(p Product) string { return p.UpdatedAt.UTC().Format(sqlTimeFormat) },
+}
+
+const sqlTimeFormat = "2006-01-02 15:04:05.999999"
+
+// variantStatuses are the statuses Products can be filtered by, those of the
+// variant table.
+var variantStatuses = []string{"ready", "out_of_stock", "limited"}
+
+// validateProductFilter rejects the filters Products cannot be filtered by.
+func validateProductFilter(f pagination.Filter) error {
+	if f.Status == "" {
+		return nil
+	}
+	for _, status := range variantStatuses {
+		if f.Status == status {
+			return nil
+		}
+	}
+	return failure.BadRequestFromString("status must be one of " + strings.Join(variantStatuses, ", "))
+}
+
+// cursorValues returns the position of the Product in a list sorted by sort.
+func (p Product) cursorValues(sort pagination.Sort) []string {
+	values := make([]string, 0, len(sort))
+	for _, field := range sort {
+		values = append(values, productCursorValues[field.Column](p))
+	}
+	return values
 }
 
 type PayloadProductAndVariant struct {
-	UserID         uuid.UUID               `json:"userId" validate:"required"`
-	BrandID        uuid.UUID               `json:"brandId" validate:"required"`
-	ProductName    string                  `json:"productName" validate:"required"`
-	VariantPayload variants.PayloadVariant `json:"variant" validate:"required"`
+	BrandID          uuid.UUID               `json:"brandId" validate:"required"`
+	ProductName      string                  `json:"productName" validate:"required"`
+	LimitedThreshold *int                    `json:"limitedThreshold" validate:"omitempty,min=0"`
+	VariantPayload   variants.PayloadVariant `json:"variant" validate:"required"`
 }
 
 type PayloadProduct struct {
-	UserID      uuid.UUID `json:"userId" validate:"required"`
-	BrandID     uuid.UUID `json:"brandId" validate:"required"`
-	ProductName string    `json:"productName" validate:"required"`
+	BrandID          uuid.UUID `json:"brandId" validate:"required"`
+	ProductName      string    `json:"productName" validate:"required"`
+	LimitedThreshold *int      `json:"limitedThreshold" validate:"omitempty,min=0"`
 }
 
 type ProductResponseFormat struct {
-	ProductID   uuid.UUID   `json:"productId"`
-	UserID      uuid.UUID   `json:"userId"`
-	BrandID     uuid.UUID   `json:"brandId"`
-	ProductName string      `json:"productName"`
-	CreatedAt   time.Time   `json:"createdAt"`
-	UpdatedAt   time.Time   `json:"updatedAt"`
-	DeletedAt   null.Time   `json:"deletedAt"`
-	CreatedBy   uuid.UUID   `json:"createdBy"`
-	UpdatedBy   uuid.UUID   `json:"updatedBy"`
-	DeletedBy   nuuid.NUUID `json:"deletedBy"`
+	ProductID        uuid.UUID   `json:"productId"`
+	UserID           uuid.UUID   `json:"userId"`
+	BrandID          uuid.UUID   `json:"brandId"`
+	ProductName      string      `json:"productName"`
+	LimitedThreshold null.Int    `json:"limitedThreshold"`
+	CreatedAt        time.Time   `json:"createdAt"`
+	UpdatedAt        time.Time   `json:"updatedAt"`
+	DeletedAt        null.Time   `json:"deletedAt"`
+	CreatedBy        uuid.UUID   `json:"createdBy"`
+	UpdatedBy        uuid.UUID   `json:"updatedBy"`
+	DeletedBy        nuuid.NUUID `json:"deletedBy"`
 }
 
 type ProductAndVariant struct {
@@ -71,19 +126,22 @@ type ProductWithVariantsResponseFormat struct {
 	Variants []variants.VariantResponseFormat `json:"variants"`
 }
 
-func (pv ProductAndVariant) NewFromPayload(payload PayloadProductAndVariant) (res ProductAndVariant, err error) {
+// NewFromPayload creates a new Product with its first Variant, whose status is
+// derived with the Product's limited threshold or else globalThreshold.
+func (pv ProductAndVariant) NewFromPayload(payload PayloadProductAndVariant, userID uuid.UUID, globalThreshold int) (res ProductAndVariant, err error) {
 	proId, _ := uuid.NewV4()
 	newPro := Product{
-		ProductID:   proId,
-		UserID:      payload.UserID,
-		BrandID:     payload.BrandID,
-		ProductName: payload.ProductName,
-		CreatedAt:   time.Now().UTC(),
-		CreatedBy:   payload.UserID,
-		UpdatedAt:   time.Now().UTC(),
-		UpdatedBy:   payload.UserID,
+		ProductID:        proId,
+		UserID:           userID,
+		BrandID:          payload.BrandID,
+		ProductName:      payload.ProductName,
+		LimitedThreshold: null.IntFromPtr(intPtr64(payload.LimitedThreshold)),
+		CreatedAt:        time.Now().UTC(),
+		CreatedBy:        userID,
+		UpdatedAt:        time.Now().UTC(),
+		UpdatedBy:        userID,
 	}
-	newVar, err := res.Variant.NewFromPayload(payload.VariantPayload, proId)
+	newVar, err := res.Variant.NewFromPayload(payload.VariantPayload, proId, userID, newPro.limitedThreshold(globalThreshold))
 	if err != nil {
 		return
 	}
@@ -92,17 +150,18 @@ func (pv ProductAndVariant) NewFromPayload(payload PayloadProductAndVariant) (re
 	return
 }
 
-func (p Product) NewFromPayload(payload PayloadProduct) (Product, error) {
+func (p Product) NewFromPayload(payload PayloadProduct, userID uuid.UUID) (Product, error) {
 	proId, _ := uuid.NewV4()
 	newPro := Product{
-		ProductID:   proId,
-		UserID:      payload.UserID,
-		BrandID:     payload.BrandID,
-		ProductName: payload.ProductName,
-		CreatedAt:   time.Now().UTC(),
-		CreatedBy:   payload.UserID,
-		UpdatedAt:   time.Now().UTC(),
-		UpdatedBy:   payload.UserID,
+		ProductID:        proId,
+		UserID:           userID,
+		BrandID:          payload.BrandID,
+		ProductName:      payload.ProductName,
+		LimitedThreshold: null.IntFromPtr(intPtr64(payload.LimitedThreshold)),
+		CreatedAt:        time.Now().UTC(),
+		CreatedBy:        userID,
+		UpdatedAt:        time.Now().UTC(),
+		UpdatedBy:        userID,
 	}
 
 	err := newPro.Validate()
@@ -132,16 +191,17 @@ func (pv *ProductAndVariant) ToResponseFormat() ProductAndVariantResponseFormat
 
 func (p *Product) ToResponseFormat() ProductResponseFormat {
 	resp := ProductResponseFormat{
-		ProductID:   p.ProductID,
-		UserID:      p.UserID,
-		BrandID:     p.BrandID,
-		ProductName: p.ProductName,
-		CreatedAt:   time.Now().UTC(),
-		CreatedBy:   p.UserID,
-		UpdatedAt:   time.Now().UTC(),
-		UpdatedBy:   p.UserID,
-		DeletedAt:   p.DeletedAt,
-		DeletedBy:   p.DeletedBy,
+		ProductID:        p.ProductID,
+		UserID:           p.UserID,
+		BrandID:          p.BrandID,
+		ProductName:      p.ProductName,
+		LimitedThreshold: p.LimitedThreshold,
+		CreatedAt:        p.CreatedAt,
+		CreatedBy:        p.CreatedBy,
+		UpdatedAt:        p.UpdatedAt,
+		UpdatedBy:        p.UpdatedBy,
+		DeletedAt:        p.DeletedAt,
+		DeletedBy:        p.DeletedBy,
 	}
 	return resp
 }
@@ -169,17 +229,32 @@ func (p *Product) SoftDelete(userID uuid.UUID) (err error) {
 	return
 }
 
-func (p *Product) Update(req PayloadProduct) (err error) {
+func (p *Product) Update(req PayloadProduct, userID uuid.UUID) (err error) {
 	p.UpdatedAt = time.Now().UTC()
-	p.UpdatedBy = req.UserID
+	p.UpdatedBy = userID
 	p.ProductName = req.ProductName
 	p.BrandID = req.BrandID
+	p.LimitedThreshold = null.IntFromPtr(intPtr64(req.LimitedThreshold))
 
 	err = p.Validate()
 
 	return
 }
 
+// limitedThreshold returns the quantity from which the Variants of the Product
+// are limited.
+func (p *Product) limitedThreshold(globalThreshold int) int {
+	return variants.LimitedThreshold(p.LimitedThreshold, globalThreshold)
+}
+
+func intPtr64(i *int) *int64 {
+	if i == nil {
+		return nil
+	}
+	i64 := int64(*i)
+	return &i64
+}
+
 func (pv ProductAndVariant) MarshalJSON() ([]byte, error) {
 	return json.Marshal(pv.ToResponseFormat())
 }
@@ -1,24 +1,44 @@
 package products
 
 import (
-	"fmt"
+	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/image"
 	"github.com/evermos/boilerplate-go/internal/domain/variants"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/pagination"
 	"github.com/gofrs/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
 type ProductRepository interface {
 	CreateWithVariant(payload ProductAndVariant) error
-	GetAllProducts(field, sort string, limit, offset int) (prods []Product, err error)
+	GetAllProducts(filter pagination.Filter, sort pagination.Sort, limit, offset int) (prods []Product, err error)
+	GetProductsByCursor(q pagination.CursorQuery) (prods []Product, err error)
+	CountProducts(filter pagination.Filter) (total int, err error)
+	ExportProducts(filter pagination.Filter, sort pagination.Sort, each func(row ExportRow) error) (err error)
+	SearchProducts(query string, mode SearchMode, limit, offset int) (results []ProductSearchResult, err error)
+	CountSearchProducts(query string, mode SearchMode) (total int, err error)
 	GetProductByID(prodId uuid.UUID) (prod Product, err error)
 	GetProductWithVariants(proId uuid.UUID) (prod ProductWithVariants, err error)
+	GetProductsWithVariants(filter pagination.Filter, sort pagination.Sort, limit, offset int) (prods []ProductWithVariants, err error)
 	Update(prod Product) (err error)
+	SyncVariantStatuses(prodId uuid.UUID, globalThreshold int) (err error)
 	HardDelete(prodId uuid.UUID) (err error)
 	AddVariant(variant variants.Variant) (err error)
+	CreateImport(imp ProductImport) (err error)
+	GetImportByID(importId uuid.UUID) (imp ProductImport, err error)
+	ClaimImport(imp ProductImport) (err error)
+	RequeueImports(staleBefore time.Time) (err error)
+	GetPendingImportIDs() (importIds []uuid.UUID, err error)
+	FinishImport(imp ProductImport) (err error)
+	GetImportBrands(refs []string) (brands []ImportBrand, err error)
+	GetImportTargets(userID uuid.UUID, keys []ImportKey) (prods []ProductWithVariants, err error)
+	ImportBatch(batch ImportBatch) (err error)
 }
 
 type ProductRepositoryMariaDB struct {
@@ -52,7 +72,7 @@ func (r *ProductRepositoryMariaDB) CreateWithVariant(payload ProductAndVariant)
 			c <- err
 			return
 		}
-		if err := r.txCreateImages(db, payload.Variant); err != nil {
+		if err := r.txCreateImages(db, payload.Variant.Images); err != nil {
 			c <- err
 			return
 		}
@@ -61,10 +81,14 @@ func (r *ProductRepositoryMariaDB) CreateWithVariant(payload ProductAndVariant)
 }
 
 func (r *ProductRepositoryMariaDB) txCreateWithVariant(tx *sqlx.Tx, payload ProductAndVariant) (err error) {
+	return r.txCreateProduct(tx, payload.Product)
+}
+
+func (r *ProductRepositoryMariaDB) txCreateProduct(tx *sqlx.Tx, prod Product) (err error) {
 
 	query := `
-		INSERT INTO product (product_id, product_name, brand_id, updated_at, created_by, created_at, updated_by,user_id)
-		VALUES (:product_id, :product_name, :brand_id, :updated_at, :created_by,:created_at,:updated_by,:user_id);
+		INSERT INTO product (product_id, product_name, limited_threshold, brand_id, updated_at, created_by, created_at, updated_by,user_id)
+		VALUES (:product_id, :product_name, :limited_threshold, :brand_id, :updated_at, :created_by,:created_at,:updated_by,:user_id);
 	`
 
 	stmt, err := tx.PrepareNamed(query)
@@ -76,7 +100,7 @@ func (r *ProductRepositoryMariaDB) txCreateWithVariant(tx *sqlx.Tx, payload Prod
 
 	defer stmt.Close()
 
-	_, err = stmt.Exec(payload.Product)
+	_, err = stmt.Exec(prod)
 	if err != nil {
 		tx.Rollback()
 		logger.ErrorWithStack(err)
@@ -87,8 +111,8 @@ func (r *ProductRepositoryMariaDB) txCreateWithVariant(tx *sqlx.Tx, payload Prod
 }
 
 func (r *ProductRepositoryMariaDB) txCreateVariant(tx *sqlx.Tx, payload variants.Variant) (err error) {
-	varQuery := `INSERT INTO variant (variant_id,product_id,variant_name,price,quantity,updated_at, created_by, created_at, updated_by)
-	 VALUES (:variant_id,:product_id,:variant_name,:price,:quantity,:updated_at, :created_by, :created_at, :updated_by)
+	varQuery := `INSERT INTO variant (variant_id,product_id,variant_name,price,status,quantity,updated_at, created_by, created_at, updated_by)
+	 VALUES (:variant_id,:product_id,:variant_name,:price,:status,:quantity,:updated_at, :created_by, :created_at, :updated_by)
 	`
 	varStmt, err := tx.PrepareNamed(varQuery)
 	if err != nil {
@@ -105,9 +129,9 @@ func (r *ProductRepositoryMariaDB) txCreateVariant(tx *sqlx.Tx, payload variants
 	return
 }
 
-func (r *ProductRepositoryMariaDB) txCreateImages(tx *sqlx.Tx, payload variants.Variant) (err error) {
-	imgQuery := `INSERT INTO image (image_id,variant_id,image_url,updated_at, created_by, created_at, updated_by)
-	VALUES (:image_id,:variant_id,:image_url,:updated_at, :created_by, :created_at, :updated_by)`
+func (r *ProductRepositoryMariaDB) txCreateImages(tx *sqlx.Tx, imgs []image.Image) (err error) {
+	imgQuery := `INSERT INTO image (image_id,variant_id,image_url,position,updated_at, created_by, created_at, updated_by)
+	VALUES (:image_id,:variant_id,:image_url,:position,:updated_at, :created_by, :created_at, :updated_by)`
 	imgStmt, err := tx.PrepareNamed(imgQuery)
 	if err != nil {
 		tx.Rollback()
@@ -116,8 +140,8 @@ func (r *ProductRepositoryMariaDB) txCreateImages(tx *sqlx.Tx, payload variants.
 	}
 	defer imgStmt.Close()
 
-	for _, imgs := range payload.Images {
-		_, err = imgStmt.Exec(imgs)
+	for _, img := range imgs {
+		_, err = imgStmt.Exec(img)
 		if err != nil {
 			tx.Rollback()
 			logger.ErrorWithStack(err)
@@ -127,9 +151,83 @@ func (r *ProductRepositoryMariaDB) txCreateImages(tx *sqlx.Tx, payload variants.
 	return
 }
 
-func (r *ProductRepositoryMariaDB) GetAllProducts(field, sort string, limit, offset int) (prods []Product, err error) {
-	query := fmt.Sprintf("SELECT * FROM product ORDER BY %s %s LIMIT %d OFFSET %d", field, sort, limit, offset)
-	err = r.DB.Read.Select(&prods, query)
+// productFilterConditions translates a filter into SQL conditions on the
+// product table and their arguments.
+func productFilterConditions(f pagination.Filter) (conditions []string, args []interface{}) {
+	if f.BrandID.Valid {
+		conditions = append(conditions, "product.brand_id = ?")
+		args = append(args, f.BrandID.UUID.String())
+	}
+	if f.UserID.Valid {
+		conditions = append(conditions, "product.user_id = ?")
+		args = append(args, f.UserID.UUID.String())
+	}
+
+	switch f.Deleted {
+	case pagination.DeletedExclude:
+		conditions = append(conditions, "product.deleted_at IS NULL")
+	case pagination.DeletedOnly:
+		conditions = append(conditions, "product.deleted_at IS NOT NULL")
+	}
+
+	if f.Name != "" {
+		conditions = append(conditions, `product.product_name LIKE ? ESCAPE '\\'`)
+		args = append(args, "%"+likeEscaper.Replace(f.Name)+"%")
+	}
+
+	for _, tr := range []struct {
+		column string
+		rng    pagination.TimeRange
+	}{{"product.created_at", f.Created}, {"product.updated_at", f.Updated}} {
+		if tr.rng.From.Valid {
+			conditions = append(conditions, tr.column+" >= ?")
+			args = append(args, tr.rng.From.Time)
+		}
+		if tr.rng.To.Valid {
+			conditions = append(conditions, tr.column+" < ?")
+			args = append(args, tr.rng.To.Time)
+		}
+	}
+
+	// Variant filters select the Products having one live variant matching
+	// all of them.
+	var variantConditions []string
+	if f.Status != "" {
+		variantConditions = append(variantConditions, "variant.status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Price.Min.Valid {
+		variantConditions = append(variantConditions, "variant.price >= ?")
+		args = append(args, f.Price.Min.Float64)
+	}
+	if f.Price.Max.Valid {
+		variantConditions = append(variantConditions, "variant.price <= ?")
+		args = append(args, f.Price.Max.Float64)
+	}
+	if len(variantConditions) > 0 {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM variant
+			WHERE variant.product_id = product.product_id AND variant.deleted_at IS NULL AND `+strings.Join(variantConditions, " AND ")+")")
+	}
+	return
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// GetAllProducts fetches a page of Products. The columns of sort come from
+// ProductFields, never from the request.
+func (r *ProductRepositoryMariaDB) GetAllProducts(filter pagination.Filter, sort pagination.Sort, limit, offset int) (prods []Product, err error) {
+	conditions, args := productFilterConditions(filter)
+	query := "SELECT * FROM product" + where(conditions) + sort.ThenBy("product_id").OrderBy() + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+	err = r.DB.Read.Select(&prods, query, args...)
 
 	if err != nil {
 		err = failure.InternalError(err)
@@ -139,8 +237,28 @@ func (r *ProductRepositoryMariaDB) GetAllProducts(field, sort string, limit, off
 	return
 }
 
-func (r *ProductRepositoryMariaDB) GetProductByID(prodId uuid.UUID) (prod Product, err error) {
-	err = r.DB.Read.Get(&prod, "SELECT * FROM product WHERE product_id = ?", prodId)
+// GetProductsByCursor fetches up to q.Limit+1 Products past the cursor of q,
+// in the order q walks the list, so that the caller can tell whether there is
+// another page.
+func (r *ProductRepositoryMariaDB) GetProductsByCursor(q pagination.CursorQuery) (prods []Product, err error) {
+	sort := q.Sort.ThenBy("product_id")
+	if q.Backward() {
+		sort = sort.Reverse()
+	}
+
+	conditions, args := productFilterConditions(q.Filter)
+	if q.Cursor != nil {
+		condition, conditionArgs, err := sort.After(q.Cursor.Values)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+	query := "SELECT * FROM product" + where(conditions) + sort.OrderBy() + " LIMIT ?"
+	args = append(args, q.Limit+1)
+
+	err = r.DB.Read.Select(&prods, query, args...)
 	if err != nil {
 		err = failure.InternalError(err)
 		logger.ErrorWithStack(err)
@@ -149,28 +267,359 @@ func (r *ProductRepositoryMariaDB) GetProductByID(prodId uuid.UUID) (prod Produc
 	return
 }
 
-func (r *ProductRepositoryMariaDB) GetProductWithVariants(prodId uuid.UUID) (prod ProductWithVariants, err error) {
-	err = r.DB.Read.Get(&prod.Product, "SELECT * FROM product WHERE product_id = ?", prodId.String())
+// CountProducts counts the Products GetAllProducts and GetProductsByCursor
+// page through.
+func (r *ProductRepositoryMariaDB) CountProducts(filter pagination.Filter) (total int, err error) {
+	conditions, args := productFilterConditions(filter)
+	err = r.DB.Read.Get(&total, "SELECT COUNT(*) FROM product"+where(conditions), args...)
 	if err != nil {
 		err = failure.InternalError(err)
 		logger.ErrorWithStack(err)
-		return
 	}
-	varQuery := fmt.Sprintf("SELECT * FROM variant WHERE product_id = '%s'", prodId.String())
-	err = r.DB.Read.Select(&prod.Variants, varQuery)
+	return
+}
+
+// exportSelect joins the Products with their live Variants and the Images of
+// those. The Product columns are named for the product field of ExportRow.
+const exportSelect = `
+	SELECT
+		product.product_id AS ` + "`product.product_id`" + `,
+		product.user_id AS ` + "`product.user_id`" + `,
+		product.brand_id AS ` + "`product.brand_id`" + `,
+		product.product_name AS ` + "`product.product_name`" + `,
+		product.limited_threshold AS ` + "`product.limited_threshold`" + `,
+		product.created_at AS ` + "`product.created_at`" + `,
+		product.updated_at AS ` + "`product.updated_at`" + `,
+		product.deleted_at AS ` + "`product.deleted_at`" + `,
+		product.created_by AS ` + "`product.created_by`" + `,
+		product.updated_by AS ` + "`product.updated_by`" + `,
+		product.deleted_by AS ` + "`product.deleted_by`" + `,
+		variant.variant_id,
+		variant.variant_name,
+		variant.price,
+		variant.quantity,
+		variant.status,
+		variant.created_at AS variant_created_at,
+		variant.updated_at AS variant_updated_at,
+		image.image_id,
+		image.image_url,
+		image.position
+	FROM product
+	LEFT JOIN variant ON variant.product_id = product.product_id AND variant.deleted_at IS NULL
+	LEFT JOIN image ON image.variant_id = variant.variant_id`
+
+// ExportProducts streams the Products GetAllProducts lists, joined with their
+// live Variants and Images, calling each for every row as it is read. Rows
+// come grouped by Product, then by Variant, so that callers only hold a
+// single Product at a time.
+func (r *ProductRepositoryMariaDB) ExportProducts(filter pagination.Filter, sort pagination.Sort, each func(row ExportRow) error) (err error) {
+	conditions, args := productFilterConditions(filter)
+	order := sort.ThenBy("product_id").Qualified("product").OrderBy() +
+		", variant.created_at, variant.variant_id, image.position, image.created_at, image.image_id"
+	rows, err := r.DB.Read.Queryx(exportSelect+where(conditions)+order, args...)
 	if err != nil {
 		err = failure.InternalError(err)
 		logger.ErrorWithStack(err)
 		return
 	}
+	defer rows.Close()
 
-	for i := 0; i < len(prod.Variants); i++ {
-		err = r.DB.Read.Select(&prod.Variants[i].Images, "SELECT * FROM image WHERE variant_id = ?", prod.Variants[i].VariantID)
+	for rows.Next() {
+		var row ExportRow
+		err = rows.StructScan(&row)
 		if err != nil {
 			err = failure.InternalError(err)
 			logger.ErrorWithStack(err)
 			return
 		}
+		err = each(row)
+		if err != nil {
+			return
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// productSearchConditions returns the relevance of a Product for a search
+// query and the condition selecting the live Products matching it. Both take
+// the query as argument three times.
+func productSearchConditions(mode SearchMode) (relevance string, condition string) {
+	against := mode.against()
+	relevance = `
+		MATCH (product.product_name) ` + against + `
+		+ COALESCE(MATCH (brand.brand_name) ` + against + `, 0)
+		+ COALESCE((
+			SELECT MAX(MATCH (variant.variant_name) ` + against + `)
+			FROM variant
+			WHERE variant.product_id = product.product_id AND variant.deleted_at IS NULL), 0)`
+	condition = `
+		product.deleted_at IS NULL AND (
+			MATCH (product.product_name) ` + against + `
+			OR MATCH (brand.brand_name) ` + against + `
+			OR EXISTS (
+				SELECT 1 FROM variant
+				WHERE variant.product_id = product.product_id AND variant.deleted_at IS NULL
+					AND MATCH (variant.variant_name) ` + against + `))`
+	return
+}
+
+type productSearchRow struct {
+	Product
+	BrandName string  `db:"brand_name"`
+	Relevance float64 `db:"relevance"`
+}
+
+// SearchProducts fetches a page of the live Products whose name, brand name or
+// variant names match query, the most relevant first, with their live
+// variants.
+func (r *ProductRepositoryMariaDB) SearchProducts(query string, mode SearchMode, limit, offset int) (results []ProductSearchResult, err error) {
+	relevance, condition := productSearchConditions(mode)
+	sqlQuery := `
+		SELECT product.*, COALESCE(brand.brand_name, '') AS brand_name, ` + relevance + ` AS relevance
+		FROM product
+		LEFT JOIN brand ON brand.brand_id = product.brand_id
+		WHERE ` + condition + `
+		ORDER BY relevance DESC, product.product_id
+		LIMIT ? OFFSET ?`
+
+	var rows []productSearchRow
+	err = r.DB.Read.Select(&rows, sqlQuery, query, query, query, query, query, query, limit, offset)
+	if err != nil {
+		err = searchError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	results = make([]ProductSearchResult, 0, len(rows))
+	prods := make([]ProductWithVariants, 0, len(rows))
+	for _, row := range rows {
+		prods = append(prods, ProductWithVariants{Product: row.Product})
+	}
+	err = r.attachVariants(prods)
+	if err != nil {
+		return
+	}
+	for i, row := range rows {
+		results = append(results, ProductSearchResult{
+			ProductWithVariants: prods[i],
+			BrandName:           row.BrandName,
+			Relevance:           row.Relevance,
+		})
+	}
+	return
+}
+
+// CountSearchProducts counts the Products SearchProducts pages through.
+func (r *ProductRepositoryMariaDB) CountSearchProducts(query string, mode SearchMode) (total int, err error) {
+	_, condition := productSearchConditions(mode)
+	sqlQuery := `
+		SELECT COUNT(*)
+		FROM product
+		LEFT JOIN brand ON brand.brand_id = product.brand_id
+		WHERE ` + condition
+
+	err = r.DB.Read.Get(&total, sqlQuery, query, query, query)
+	if err != nil {
+		err = searchError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// resolveLiveVariants fetches the live variants of several Products with
+// their images and stock locations.
+func (r *ProductRepositoryMariaDB) resolveLiveVariants(prodIds []string) (varis []variants.Variant, err error) {
+	if len(prodIds) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM variant WHERE product_id IN (?) AND deleted_at IS NULL ORDER BY created_at, variant_id", prodIds)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = r.DB.Read.Select(&varis, query, args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = r.attachImages(varis)
+	if err != nil {
+		return
+	}
+	err = r.attachLocations(varis)
+	return
+}
+
+// attachVariants fetches the live variants of several Products at once.
+func (r *ProductRepositoryMariaDB) attachVariants(prods []ProductWithVariants) (err error) {
+	ids := make([]string, 0, len(prods))
+	for _, prod := range prods {
+		ids = append(ids, prod.Product.ProductID.String())
+	}
+	varis, err := r.resolveLiveVariants(ids)
+	if err != nil {
+		return
+	}
+
+	byProduct := make(map[uuid.UUID][]variants.Variant, len(prods))
+	for _, vari := range varis {
+		byProduct[vari.ProductID] = append(byProduct[vari.ProductID], vari)
+	}
+	for i := range prods {
+		prods[i].Variants = byProduct[prods[i].Product.ProductID]
+		if prods[i].Variants == nil {
+			prods[i].Variants = []variants.Variant{}
+		}
+	}
+	return
+}
+
+// attachImages fetches the images of several variants, with their renditions,
+// at once.
+func (r *ProductRepositoryMariaDB) attachImages(varis []variants.Variant) (err error) {
+	if len(varis) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(varis))
+	for _, vari := range varis {
+		ids = append(ids, vari.VariantID.String())
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM image WHERE variant_id IN (?) ORDER BY position, created_at, image_id", ids)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	var imgs []image.Image
+	err = r.DB.Read.Select(&imgs, query, args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = image.AttachRenditions(r.DB.Read, imgs)
+	if err != nil {
+		return
+	}
+
+	byVariant := make(map[uuid.UUID][]image.Image, len(varis))
+	for _, img := range imgs {
+		byVariant[img.VariantID] = append(byVariant[img.VariantID], img)
+	}
+	for i := range varis {
+		varis[i].Images = byVariant[varis[i].VariantID]
+	}
+	return
+}
+
+func (r *ProductRepositoryMariaDB) GetProductByID(prodId uuid.UUID) (prod Product, err error) {
+	err = r.DB.Read.Get(&prod, "SELECT * FROM product WHERE product_id = ?", prodId)
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("product")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+// GetProductWithVariants fetches a Product with all its variants, their images
+// and stock locations, in a fixed number of queries.
+func (r *ProductRepositoryMariaDB) GetProductWithVariants(prodId uuid.UUID) (prod ProductWithVariants, err error) {
+	err = r.DB.Read.Get(&prod.Product, "SELECT * FROM product WHERE product_id = ?", prodId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("product")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = r.DB.Read.Select(&prod.Variants, "SELECT * FROM variant WHERE product_id = ? ORDER BY created_at, variant_id", prodId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = r.attachImages(prod.Variants)
+	if err != nil {
+		return
+	}
+	err = r.attachLocations(prod.Variants)
+	return
+}
+
+// GetProductsWithVariants fetches a page of Products, as GetAllProducts does,
+// with their live variants, images and stock locations. The number of queries
+// does not depend on the size of the page.
+func (r *ProductRepositoryMariaDB) GetProductsWithVariants(filter pagination.Filter, sort pagination.Sort, limit, offset int) (prods []ProductWithVariants, err error) {
+	page, err := r.GetAllProducts(filter, sort, limit, offset)
+	if err != nil {
+		return
+	}
+
+	prods = make([]ProductWithVariants, 0, len(page))
+	for _, prod := range page {
+		prods = append(prods, ProductWithVariants{Product: prod})
+	}
+	err = r.attachVariants(prods)
+	return
+}
+
+func (r *ProductRepositoryMariaDB) attachLocations(varis []variants.Variant) (err error) {
+	if len(varis) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(varis))
+	for _, vari := range varis {
+		ids = append(ids, vari.VariantID.String())
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT
+			vl.variant_location_id,
+			vl.warehouse_id,
+			w.warehouse_name,
+			vl.variant_id,
+			vl.variant_quantity
+		FROM variant_location vl
+		JOIN warehouse w ON w.warehouse_id = vl.warehouse_id
+		WHERE vl.variant_id IN (?)
+		ORDER BY vl.warehouse_id`, ids)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	var locs []variants.Location
+	err = r.DB.Read.Select(&locs, query, args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	for i := range varis {
+		varis[i].Locations = []variants.Location{}
+		varis[i].AttachLocations(locs)
 	}
 	return
 }
@@ -207,50 +656,54 @@ func (r *ProductRepositoryMariaDB) Update(prod Product) (err error) {
 	})
 }
 
+// txUpdate writes a Product. Soft deleting a Product soft deletes its live
+// variants along with it, the others keep their own deletion.
 func (r *ProductRepositoryMariaDB) txUpdate(tx *sqlx.Tx, prod Product) (err error) {
 	query := `UPDATE product
 	SET
 		product_name = :product_name,
+		limited_threshold = :limited_threshold,
 		brand_id = :brand_id,
 		updated_at = :updated_at,
 		updated_by = :updated_by,
 		deleted_at = :deleted_at,
 		deleted_by = :deleted_by
 	WHERE product_id = :product_id`
-	varQuery := `UPDATE variant 
+	varQuery := `UPDATE variant
 	SET
-		updated_at = :updated_at,
-		updated_by = :updated_by,
+		updated_at = :deleted_at,
+		updated_by = :deleted_by,
 		deleted_at = :deleted_at,
 		deleted_by = :deleted_by
-	WHERE product_id = :product_id`
+	WHERE product_id = :product_id AND deleted_at IS NULL`
 
-	stmt, err := tx.PrepareNamed(query)
+	_, err = tx.NamedExec(query, prod)
 	if err != nil {
 		tx.Rollback()
 		logger.ErrorWithStack(err)
 		return
 	}
-	defer stmt.Close()
-	varsStmt, err := tx.PrepareNamed(varQuery)
-	if err != nil {
-		tx.Rollback()
-		logger.ErrorWithStack(err)
+	if !prod.IsDeleted() {
 		return
 	}
-	defer varsStmt.Close()
 
-	_, err = stmt.Exec(prod)
+	_, err = tx.NamedExec(varQuery, prod)
 	if err != nil {
 		tx.Rollback()
 		logger.ErrorWithStack(err)
+		return
 	}
-	_, err = varsStmt.Exec(prod)
+	return
+}
+
+// SyncVariantStatuses derives the status of the Variants of a Product from
+// their quantity again, after its limited threshold changed.
+func (r *ProductRepositoryMariaDB) SyncVariantStatuses(prodId uuid.UUID, globalThreshold int) (err error) {
+	_, err = r.DB.Write.Exec(variants.SyncStatusQuery+" WHERE variant.product_id = ?", globalThreshold, prodId.String())
 	if err != nil {
-		tx.Rollback()
+		err = failure.InternalError(err)
 		logger.ErrorWithStack(err)
 	}
-
 	return
 }
 
@@ -277,10 +730,314 @@ func (r *ProductRepositoryMariaDB) AddVariant(variant variants.Variant) (err err
 			c <- err
 			return
 		}
-		if err := r.txCreateImages(db, variant); err != nil {
+		if err := r.txCreateImages(db, variant.Images); err != nil {
 			c <- err
 			return
 		}
 		c <- nil
 	})
 }
+
+var (
+	importQueries = struct {
+		insertImport   string
+		selectImport   string
+		updateImport   string
+		claimImport    string
+		requeueImports string
+		selectPending  string
+		insertError    string
+		selectErrors   string
+		selectBrands   string
+		updateProduct  string
+		selectProducts string
+	}{
+		insertImport: `
+			INSERT INTO product_import (import_id, user_id, file_name, format, blob_key, dry_run, status, created_at, updated_at)
+			VALUES (:import_id, :user_id, :file_name, :format, :blob_key, :dry_run, :status, :created_at, :updated_at)`,
+
+		selectImport: `
+			SELECT
+				import_id,
+				user_id,
+				file_name,
+				format,
+				blob_key,
+				dry_run,
+				status,
+				message,
+				total_rows,
+				failed_rows,
+				created_products,
+				updated_products,
+				created_variants,
+				updated_variants,
+				created_at,
+				updated_at,
+				finished_at
+			FROM product_import
+			WHERE import_id = ?`,
+
+		updateImport: `
+			UPDATE product_import
+			SET
+				status = :status,
+				message = :message,
+				total_rows = :total_rows,
+				failed_rows = :failed_rows,
+				created_products = :created_products,
+				updated_products = :updated_products,
+				created_variants = :created_variants,
+				updated_variants = :updated_variants,
+				updated_at = :updated_at,
+				finished_at = :finished_at
+			WHERE import_id = :import_id`,
+
+		// claimImport only starts pending imports, so that an import is never
+		// run twice at once.
+		claimImport: `
+			UPDATE product_import
+			SET status = :status, updated_at = :updated_at
+			WHERE import_id = :import_id AND status = 'pending'`,
+
+		requeueImports: `
+			UPDATE product_import
+			SET status = 'pending', updated_at = ?
+			WHERE status = 'running' AND updated_at < ?`,
+
+		selectPending: `
+			SELECT import_id
+			FROM product_import
+			WHERE status = 'pending'
+			ORDER BY created_at, import_id`,
+
+		insertError: `
+			INSERT INTO product_import_error (import_id, line, message)
+			VALUES (:import_id, :line, :message)`,
+
+		selectErrors: `
+			SELECT import_id, line, message
+			FROM product_import_error
+			WHERE import_id = ?
+			ORDER BY line, product_import_error_id`,
+
+		selectBrands: `
+			SELECT brand_id, brand_name
+			FROM brand
+			WHERE deleted_at IS NULL AND (brand_id IN (?) OR brand_name IN (?))`,
+
+		updateProduct: `
+			UPDATE product
+			SET
+				limited_threshold = :limited_threshold,
+				updated_at = :updated_at,
+				updated_by = :updated_by
+			WHERE product_id = :product_id`,
+
+		selectProducts: `SELECT * FROM product WHERE user_id = ? AND deleted_at IS NULL`,
+	}
+)
+
+// ImportKey identifies a Product among those of a user, as rows of an import
+// name it.
+type ImportKey struct {
+	BrandID     uuid.UUID
+	ProductName string
+}
+
+func (r *ProductRepositoryMariaDB) CreateImport(imp ProductImport) (err error) {
+	_, err = r.DB.Write.NamedExec(importQueries.insertImport, imp)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// GetImportByID resolves a ProductImport along with the errors of its rows.
+func (r *ProductRepositoryMariaDB) GetImportByID(importId uuid.UUID) (imp ProductImport, err error) {
+	err = r.DB.Read.Get(&imp, importQueries.selectImport, importId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("product import")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	imp.Errors = []ImportError{}
+	err = r.DB.Read.Select(&imp.Errors, importQueries.selectErrors, importId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// ClaimImport saves a started ProductImport, failing with a Conflict when it
+// was started elsewhere in the meantime.
+func (r *ProductRepositoryMariaDB) ClaimImport(imp ProductImport) (err error) {
+	result, err := r.DB.Write.NamedExec(importQueries.claimImport, imp)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	if affected == 0 {
+		err = failure.Conflict("start", "ProductImport", "was started already")
+	}
+	return
+}
+
+// RequeueImports puts the imports left running since before staleBefore, by a
+// process which stopped, back to pending.
+func (r *ProductRepositoryMariaDB) RequeueImports(staleBefore time.Time) (err error) {
+	_, err = r.DB.Write.Exec(importQueries.requeueImports, time.Now().UTC(), staleBefore)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// GetPendingImportIDs returns the IDs of the imports waiting to be run, the
+// oldest first.
+func (r *ProductRepositoryMariaDB) GetPendingImportIDs() (importIds []uuid.UUID, err error) {
+	err = r.DB.Read.Select(&importIds, importQueries.selectPending)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// FinishImport saves a finished ProductImport along with the errors of its
+// rows.
+func (r *ProductRepositoryMariaDB) FinishImport(imp ProductImport) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		_, err := tx.NamedExec(importQueries.updateImport, imp)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+
+		for _, e := range imp.Errors {
+			e.ImportID = imp.ImportID
+			_, err = tx.NamedExec(importQueries.insertError, e)
+			if err != nil {
+				logger.ErrorWithStack(err)
+				c <- err
+				return
+			}
+		}
+		c <- nil
+	})
+}
+
+// GetImportBrands fetches the live Brands whose ID or name is among refs.
+func (r *ProductRepositoryMariaDB) GetImportBrands(refs []string) (brands []ImportBrand, err error) {
+	if len(refs) == 0 {
+		return
+	}
+
+	query, args, err := sqlx.In(importQueries.selectBrands, refs, refs)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = r.DB.Read.Select(&brands, query, args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// GetImportTargets fetches the live Products of a user named by keys, with
+// their live Variants, to be updated by an import.
+func (r *ProductRepositoryMariaDB) GetImportTargets(userID uuid.UUID, keys []ImportKey) (prods []ProductWithVariants, err error) {
+	if len(keys) == 0 {
+		return
+	}
+
+	conditions := make([]string, 0, len(keys))
+	args := []interface{}{userID.String()}
+	for _, key := range keys {
+		conditions = append(conditions, "(brand_id = ? AND product_name = ?)")
+		args = append(args, key.BrandID.String(), key.ProductName)
+	}
+
+	var found []Product
+	err = r.DB.Read.Select(&found, importQueries.selectProducts+" AND ("+strings.Join(conditions, " OR ")+")", args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	prods = make([]ProductWithVariants, 0, len(found))
+	for _, prod := range found {
+		prods = append(prods, ProductWithVariants{Product: prod})
+	}
+	err = r.attachVariants(prods)
+	return
+}
+
+// ImportBatch writes a batch of an import in a single transaction. Products
+// whose limited threshold changed have the status of their other Variants
+// derived again.
+func (r *ProductRepositoryMariaDB) ImportBatch(batch ImportBatch) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		for _, prod := range batch.NewProducts {
+			if err := r.txCreateProduct(tx, prod); err != nil {
+				c <- err
+				return
+			}
+		}
+		for _, prod := range batch.UpdatedProducts {
+			if _, err := tx.NamedExec(importQueries.updateProduct, prod); err != nil {
+				logger.ErrorWithStack(err)
+				c <- err
+				return
+			}
+		}
+		for _, vari := range batch.NewVariants {
+			if err := r.txCreateVariant(tx, vari); err != nil {
+				c <- err
+				return
+			}
+			if err := r.txCreateImages(tx, vari.Images); err != nil {
+				c <- err
+				return
+			}
+		}
+		for _, vari := range batch.UpdatedVariants {
+			if err := variants.TxUpdate(tx, vari, batch.GlobalThreshold); err != nil {
+				c <- err
+				return
+			}
+		}
+		if err := r.txCreateImages(tx, batch.NewImages); err != nil {
+			c <- err
+			return
+		}
+		for _, prod := range batch.UpdatedProducts {
+			if _, err := tx.Exec(variants.SyncStatusQuery+" WHERE variant.product_id = ?", batch.GlobalThreshold, prod.ProductID.String()); err != nil {
+				logger.ErrorWithStack(err)
+				c <- err
+				return
+			}
+		}
+		c <- nil
+	})
+}
@@ -0,0 +1,162 @@
+package products
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/go-sql-driver/mysql"
+)
+
+// SearchMode selects how a search query is matched against the FULLTEXT
+// indexes, see MATCH ... AGAINST.
+type SearchMode string
+
+const (
+	// SearchNatural ranks Products by how well they match the words of the
+	// query.
+	SearchNatural SearchMode = "natural"
+	// SearchBoolean reads the query in the boolean syntax, where +word is
+	// required, -word excluded and word* a prefix.
+	SearchBoolean SearchMode = "boolean"
+)
+
+// ParseSearchMode reads a search mode, natural being the default.
+func ParseSearchMode(s string) (SearchMode, error) {
+	switch SearchMode(strings.ToLower(s)) {
+	case "", SearchNatural:
+		return SearchNatural, nil
+	case SearchBoolean:
+		return SearchBoolean, nil
+	}
+	return "", failure.BadRequestFromString("mode must be natural or boolean")
+}
+
+func (m SearchMode) against() string {
+	if m == SearchBoolean {
+		return "AGAINST (? IN BOOLEAN MODE)"
+	}
+	return "AGAINST (? IN NATURAL LANGUAGE MODE)"
+}
+
+// errParse is the MariaDB error number of a query it cannot parse. Search
+// queries being fixed, only a malformed boolean search query triggers it.
+const errParse = 1064
+
+// searchError reports the boolean search queries MariaDB rejects, such as one
+// with a stray quote, as bad requests, and other errors as internal ones.
+func searchError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errParse {
+		return failure.BadRequestFromString("q is not a valid boolean search query")
+	}
+	return failure.InternalError(err)
+}
+
+// ProductSearchResult is a Product found by a full-text search, along with its
+// relevance and the searched fields that matched.
+type ProductSearchResult struct {
+	ProductWithVariants
+	BrandName  string
+	Relevance  float64
+	Highlights ProductHighlights
+}
+
+// ProductHighlights are the searched fields of a Product that match a query,
+// HTML escaped and with the matching words wrapped in <em> tags.
+type ProductHighlights struct {
+	ProductName  string   `json:"productName,omitempty"`
+	BrandName    string   `json:"brandName,omitempty"`
+	VariantNames []string `json:"variantNames,omitempty"`
+}
+
+type ProductSearchResultResponseFormat struct {
+	Product    ProductResponseFormat
+	Variants   []variants.VariantResponseFormat `json:"variants"`
+	Relevance  float64                          `json:"relevance"`
+	Highlights ProductHighlights                `json:"highlights"`
+}
+
+func (psr *ProductSearchResult) ToResponseFormat() ProductSearchResultResponseFormat {
+	pv := psr.ProductWithVariants.ToResponseFormat()
+	return ProductSearchResultResponseFormat{
+		Product:    pv.Product,
+		Variants:   pv.Variants,
+		Relevance:  psr.Relevance,
+		Highlights: psr.Highlights,
+	}
+}
+
+func (psr ProductSearchResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(psr.ToResponseFormat())
+}
+
+// Highlight fills the Highlights of the result for query.
+func (psr *ProductSearchResult) Highlight(query string) {
+	pattern := highlightPattern(query)
+	if pattern == nil {
+		return
+	}
+
+	psr.Highlights.ProductName, _ = highlight(pattern, psr.Product.ProductName)
+	psr.Highlights.BrandName, _ = highlight(pattern, psr.BrandName)
+	for _, vari := range psr.Variants {
+		if name, ok := highlight(pattern, vari.VariantName); ok {
+			psr.Highlights.VariantNames = append(psr.Highlights.VariantNames, name)
+		}
+	}
+}
+
+// highlightPattern matches the words of a search query that select Products,
+// leaving out the ones excluded with the boolean - operator.
+func highlightPattern(query string) *regexp.Regexp {
+	var alternatives []string
+	for _, word := range strings.Fields(query) {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+
+		word = strings.TrimLeft(word, `+~<>("`)
+		prefix := strings.HasSuffix(strings.TrimRight(word, `)"`), "*")
+		word = strings.TrimRight(word, `)"*`)
+		if word == "" {
+			continue
+		}
+
+		alternative := regexp.QuoteMeta(word)
+		if prefix {
+			alternative += `\w*`
+		}
+		alternatives = append(alternatives, alternative)
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b`)
+}
+
+// highlight returns text HTML escaped with the matches of pattern wrapped in
+// <em> tags, or an empty string if nothing matches.
+func highlight(pattern *regexp.Regexp, text string) (highlighted string, ok bool) {
+	matches := pattern.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		b.WriteString(html.EscapeString(text[last:match[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(text[match[0]:match[1]]))
+		b.WriteString("</em>")
+		last = match[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String(), true
+}
@@ -0,0 +1,60 @@
+package products_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/products"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	result := products.ProductSearchResult{
+		ProductWithVariants: products.ProductWithVariants{
+			Product:  products.Product{ProductName: "Cotton <Shirt>"},
+			Variants: []variants.Variant{{VariantName: "Blue shirts"}, {VariantName: "Red"}},
+		},
+		BrandName: "Shirtworks",
+	}
+
+	result.Highlight("+shirt* -red")
+
+	assert.Equal(t, "Cotton &lt;<em>Shirt</em>&gt;", result.Highlights.ProductName)
+	assert.Equal(t, "<em>Shirtworks</em>", result.Highlights.BrandName)
+	assert.Equal(t, []string{"Blue <em>shirts</em>"}, result.Highlights.VariantNames)
+}
+
+func TestParseSearchMode(t *testing.T) {
+	mode, err := products.ParseSearchMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, products.SearchNatural, mode)
+
+	_, err = products.ParseSearchMode("regex")
+	assert.Error(t, err)
+}
+
+func TestSearchRejectsMalformedBooleanQueries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn := sqlx.NewDb(db, "mysql")
+	repo := products.ProvideProductRepositoryMariaDB(&infras.MariaDBConn{Read: conn, Write: conn})
+
+	mock.ExpectQuery("SELECT COUNT").
+		WillReturnError(&mysql.MySQLError{Number: 1064, Message: "syntax error, unexpected $end"})
+	_, err = repo.CountSearchProducts(`"shirt`, products.SearchBoolean)
+	assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+
+	mock.ExpectQuery("SELECT COUNT").
+		WillReturnError(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"})
+	_, err = repo.CountSearchProducts("shirt", products.SearchBoolean)
+	assert.Equal(t, http.StatusInternalServerError, failure.GetCode(err))
+}
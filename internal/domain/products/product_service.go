@@ -1,37 +1,76 @@
 package products
 
 import (
+	"io"
+	"net/http"
+	"strings"
+
 	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
 	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/blob"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/pagination"
 	"github.com/gofrs/uuid"
 )
 
 type ProductService interface {
-	CreateWithVariant(newMat PayloadProductAndVariant) (ProductAndVariant, error)
-	GetAllProducts(pg pagination.Pagination) (prods []Product, err error)
+	CreateWithVariant(newMat PayloadProductAndVariant, userID uuid.UUID) (ProductAndVariant, error)
+	GetAllProducts(pg pagination.Pagination) (prods []Product, total int, err error)
+	GetAllProductsWithVariants(pg pagination.Pagination) (prods []ProductWithVariants, total int, err error)
+	GetProductsByCursor(q pagination.CursorQuery) (prods []Product, page pagination.CursorPage, total int, err error)
+	ExportProducts(filter pagination.Filter, sort pagination.Sort, format ExportFormat, w io.Writer) (err error)
+	SearchProducts(query string, mode SearchMode, pg pagination.Pagination) (results []ProductSearchResult, total int, err error)
 	GetProductByID(prodId uuid.UUID) (prod ProductWithVariants, err error)
-	Update(prodId uuid.UUID, payload PayloadProduct) (prod Product, err error)
-	SoftDelete(prodId uuid.UUID, payload PayloadProduct) (prod Product, err error)
-	HardDelete(prodId uuid.UUID, payload PayloadProduct) (err error)
-	AddVariant(prodId uuid.UUID, payload variants.PayloadVariant) (variant variants.Variant, err error)
+	GetProductOwner(prodId uuid.UUID) (ownerID uuid.UUID, err error)
+	Update(prodId uuid.UUID, payload PayloadProduct, userID uuid.UUID) (prod Product, err error)
+	SoftDelete(prodId uuid.UUID, userID uuid.UUID) (prod Product, err error)
+	HardDelete(prodId uuid.UUID) (err error)
+	AddVariant(prodId uuid.UUID, payload variants.PayloadVariant, userID uuid.UUID) (variant variants.Variant, err error)
+	StartImport(file io.ReadSeeker, fileName string, size int64, dryRun bool, userID uuid.UUID) (imp ProductImport, err error)
+	GetImportByID(importId uuid.UUID) (imp ProductImport, err error)
+	GetImportOwner(importId uuid.UUID) (ownerID uuid.UUID, err error)
+	RunImport(importId uuid.UUID) (err error)
+	ResumeImports() (resumed int, err error)
+	MaxImportSize() int64
 }
 
 type ProductServiceImpl struct {
 	ProductRepository ProductRepository
+	UserService       user.UserService
+	Store             blob.Store
+	PubSub            shared.PubSub
 	Config            *configs.Config
 }
 
-func ProvideProductServiceImpl(ProductRepo ProductRepository, config *configs.Config) *ProductServiceImpl {
+func ProvideProductServiceImpl(ProductRepo ProductRepository, userService user.UserService, store blob.Store, pubsub shared.PubSub, config *configs.Config) *ProductServiceImpl {
 	s := new(ProductServiceImpl)
 	s.ProductRepository = ProductRepo
+	s.UserService = userService
+	s.Store = store
+	s.PubSub = pubsub
 	s.Config = config
 
 	return s
 }
 
-func (s *ProductServiceImpl) CreateWithVariant(payload PayloadProductAndVariant) (ProductAndVariant ProductAndVariant, err error) {
-	ProductAndVariant, err = ProductAndVariant.NewFromPayload(payload)
+// checkUser makes sure the acting user still exists and has not been soft
+// deleted since its access token was issued.
+func (s *ProductServiceImpl) checkUser(userId uuid.UUID) (err error) {
+	_, err = s.UserService.ResolveActiveUser(userId)
+	if failure.GetCode(err) == http.StatusNotFound {
+		err = failure.Unauthorized("access token does not belong to an active user")
+	}
+	return
+}
+
+func (s *ProductServiceImpl) CreateWithVariant(payload PayloadProductAndVariant, userID uuid.UUID) (ProductAndVariant ProductAndVariant, err error) {
+	err = s.checkUser(userID)
+	if err != nil {
+		return
+	}
+	ProductAndVariant, err = ProductAndVariant.NewFromPayload(payload, userID, s.Config.Variant.LimitedThreshold)
 	if err != nil {
 		return
 	}
@@ -42,45 +81,175 @@ func (s *ProductServiceImpl) CreateWithVariant(payload PayloadProductAndVariant)
 	return
 }
 
-func (s *ProductServiceImpl) GetAllProducts(pg pagination.Pagination) (prods []Product, err error) {
-	prods, err = s.ProductRepository.GetAllProducts(pg.Field, pg.Sort, pg.Limit, pg.Offset)
+func (s *ProductServiceImpl) GetAllProducts(pg pagination.Pagination) (prods []Product, total int, err error) {
+	err = validateProductFilter(pg.Filter)
+	if err != nil {
+		return
+	}
+
+	prods, err = s.ProductRepository.GetAllProducts(pg.Filter, pg.Order, pg.Limit, pg.Offset)
+
+	if err != nil {
+		return
+	}
+
+	total, err = s.ProductRepository.CountProducts(pg.Filter)
+	return
+}
+
+// GetAllProductsWithVariants fetches a page of Products along with their live
+// Variants.
+func (s *ProductServiceImpl) GetAllProductsWithVariants(pg pagination.Pagination) (prods []ProductWithVariants, total int, err error) {
+	err = validateProductFilter(pg.Filter)
+	if err != nil {
+		return
+	}
+
+	prods, err = s.ProductRepository.GetProductsWithVariants(pg.Filter, pg.Order, pg.Limit, pg.Offset)
+	if err != nil {
+		return
+	}
+
+	total, err = s.ProductRepository.CountProducts(pg.Filter)
+	return
+}
+
+// GetProductsByCursor fetches a page of Products with keyset pagination, along
+// with the cursors of the pages around it.
+func (s *ProductServiceImpl) GetProductsByCursor(q pagination.CursorQuery) (prods []Product, page pagination.CursorPage, total int, err error) {
+	err = validateProductFilter(q.Filter)
+	if err != nil {
+		return
+	}
+
+	prods, err = s.ProductRepository.GetProductsByCursor(q)
+	if err != nil {
+		return
+	}
+
+	total, err = s.ProductRepository.CountProducts(q.Filter)
+	if err != nil {
+		return
+	}
+
+	hasMore := len(prods) > q.Limit
+	if hasMore {
+		prods = prods[:q.Limit]
+	}
+	if q.Backward() {
+		for i, j := 0, len(prods)-1; i < j; i, j = i+1, j-1 {
+			prods[i], prods[j] = prods[j], prods[i]
+		}
+	}
+	if len(prods) == 0 {
+		return
+	}
+
+	keyset := q.Sort.ThenBy("product_id")
+	page = pagination.NewCursorPage(q, prods[0].cursorValues(keyset), prods[len(prods)-1].cursorValues(keyset), hasMore)
+	return
+}
+
+// ExportProducts writes the Products GetAllProducts lists, with their live
+// Variants and Images, to w in format. Products are written as they are read,
+// so that the export is never held in memory as a whole.
+func (s *ProductServiceImpl) ExportProducts(filter pagination.Filter, sort pagination.Sort, format ExportFormat, w io.Writer) (err error) {
+	ew := NewExportWriter(format, w)
+	var current *ExportProduct
+	err = s.ProductRepository.ExportProducts(filter, sort, func(row ExportRow) error {
+		if current != nil && current.ProductID != row.Product.ProductID {
+			if err := ew.Write(*current); err != nil {
+				return err
+			}
+			current = nil
+		}
+		if current == nil {
+			prod := newExportProduct(row.Product)
+			current = &prod
+		}
+		current.add(row)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	if current != nil {
+		err = ew.Write(*current)
+		if err != nil {
+			return
+		}
+	}
+	return ew.Flush()
+}
+
+// SearchProducts runs a full-text search over Products, highlighting the
+// matching fields of every result.
+func (s *ProductServiceImpl) SearchProducts(query string, mode SearchMode, pg pagination.Pagination) (results []ProductSearchResult, total int, err error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		err = failure.BadRequestFromString("q is required")
+		return
+	}
 
+	results, err = s.ProductRepository.SearchProducts(query, mode, pg.Limit, pg.Offset)
 	if err != nil {
 		return
 	}
+	for i := range results {
+		results[i].Highlight(query)
+	}
 
+	total, err = s.ProductRepository.CountSearchProducts(query, mode)
 	return
 }
 
 func (s *ProductServiceImpl) GetProductByID(prodId uuid.UUID) (prod ProductWithVariants, err error) {
-	prod, err = s.ProductRepository.GetProductWithVariants(prodId)
+	return s.ProductRepository.GetProductWithVariants(prodId)
+}
+
+// GetProductOwner returns the ID of the user owning a Product.
+func (s *ProductServiceImpl) GetProductOwner(prodId uuid.UUID) (ownerID uuid.UUID, err error) {
+	prod, err := s.ProductRepository.GetProductByID(prodId)
 	if err != nil {
 		return
 	}
-	println(prod.Variants[0].Images)
+	ownerID = prod.UserID
 	return
 }
 
-func (s *ProductServiceImpl) Update(prodId uuid.UUID, payload PayloadProduct) (prod Product, err error) {
+func (s *ProductServiceImpl) Update(prodId uuid.UUID, payload PayloadProduct, userID uuid.UUID) (prod Product, err error) {
+	err = s.checkUser(userID)
+	if err != nil {
+		return
+	}
 	prod, err = s.ProductRepository.GetProductByID(prodId)
 	if err != nil {
 		return
 	}
-	err = prod.Update(payload)
+	threshold := prod.LimitedThreshold
+	err = prod.Update(payload, userID)
 	if err != nil {
 		return
 	}
 	err = s.ProductRepository.Update(prod)
+	if err != nil || threshold == prod.LimitedThreshold {
+		return
+	}
+	err = s.ProductRepository.SyncVariantStatuses(prodId, s.Config.Variant.LimitedThreshold)
 	return
 }
 
-func (s *ProductServiceImpl) SoftDelete(prodId uuid.UUID, payload PayloadProduct) (prod Product, err error) {
+func (s *ProductServiceImpl) SoftDelete(prodId uuid.UUID, userID uuid.UUID) (prod Product, err error) {
+	err = s.checkUser(userID)
+	if err != nil {
+		return
+	}
 	prod, err = s.ProductRepository.GetProductByID(prodId)
 	if err != nil {
 		return
 	}
 
-	err = prod.SoftDelete(payload.UserID)
+	err = prod.SoftDelete(userID)
 	if err != nil {
 		return
 	}
@@ -88,7 +257,7 @@ func (s *ProductServiceImpl) SoftDelete(prodId uuid.UUID, payload PayloadProduct
 	return
 }
 
-func (s *ProductServiceImpl) HardDelete(prodId uuid.UUID, payload PayloadProduct) (err error) {
+func (s *ProductServiceImpl) HardDelete(prodId uuid.UUID) (err error) {
 	_, err = s.ProductRepository.GetProductByID(prodId)
 	if err != nil {
 		return
@@ -97,8 +266,16 @@ func (s *ProductServiceImpl) HardDelete(prodId uuid.UUID, payload PayloadProduct
 	return
 }
 
-func (s *ProductServiceImpl) AddVariant(prodId uuid.UUID, payload variants.PayloadVariant) (variant variants.Variant, err error) {
-	variant, err = variant.NewFromPayload(payload, prodId)
+func (s *ProductServiceImpl) AddVariant(prodId uuid.UUID, payload variants.PayloadVariant, userID uuid.UUID) (variant variants.Variant, err error) {
+	err = s.checkUser(userID)
+	if err != nil {
+		return
+	}
+	prod, err := s.ProductRepository.GetProductByID(prodId)
+	if err != nil {
+		return
+	}
+	variant, err = variant.NewFromPayload(payload, prodId, userID, prod.limitedThreshold(s.Config.Variant.LimitedThreshold))
 	if err != nil {
 		return
 	}
@@ -1,11 +1,17 @@
 package user
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Role int
@@ -15,32 +21,194 @@ const (
 	Regular
 )
 
+func (r Role) String() string {
+	switch r {
+	case Admin:
+		return "admin"
+	case Regular:
+		return "regular"
+	default:
+		return ""
+	}
+}
+
+// ParseRole maps the role names used in payloads and in the user.role column
+// to their Role constant.
+func ParseRole(s string) (Role, error) {
+	switch s {
+	case "admin":
+		return Admin, nil
+	case "regular":
+		return Regular, nil
+	default:
+		return Regular, fmt.Errorf("unknown role %q", s)
+	}
+}
+
+// Scan implements the Scanner interface for the user.role ENUM column.
+func (r *Role) Scan(value interface{}) (err error) {
+	switch x := value.(type) {
+	case []byte:
+		*r, err = ParseRole(string(x))
+	case string:
+		*r, err = ParseRole(x)
+	default:
+		err = fmt.Errorf("cannot scan type %T into user.Role: %v", value, value)
+	}
+	return
+}
+
+// Value implements the driver Valuer interface.
+func (r Role) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
 type User struct {
-	ID        uuid.UUID
-	Username  string
-	Email     string
-	Role      Role
-	CreatedAt time.Time
-	UpdatedAt time.Time   `db:"updated"`
-	DeletedAt null.Time   `db:"deleted"`
+	ID        uuid.UUID   `db:"user_id" validate:"required"`
+	Username  string      `db:"username" validate:"required,max=50"`
+	Email     string      `db:"email" validate:"required,email,max=100"`
+	Role      Role        `db:"role" validate:"min=0,max=1"`
+	Password  string      `db:"password"`
+	CreatedAt time.Time   `db:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at"`
+	DeletedAt null.Time   `db:"deleted_at"`
 	UpdatedBy nuuid.NUUID `db:"updated_by"`
 	DeletedBy nuuid.NUUID `db:"deleted_by"`
 }
 
 type PayloadUser struct {
-	Username string
-	Email    string
-	Role     string
+	Username string `json:"username" validate:"required,max=50"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	Password string `json:"password" validate:"omitempty,min=8,max=72"`
+}
+
+type PayloadUserRole struct {
+	Role string `json:"role" validate:"required,oneof=admin regular"`
 }
 
 type UserResponseFormat struct {
-	ID        uuid.UUID `json:"userId"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	DeletedAt null.Time `json:"deletedAt"`
-	UpdatedBy uuid.UUID `json:"updatedBy"`
-	DeletedBy uuid.UUID `json:"deletedBy"`
+	ID        uuid.UUID   `json:"userId"`
+	Username  string      `json:"username"`
+	Email     string      `json:"email"`
+	Role      string      `json:"role"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	DeletedAt null.Time   `json:"deletedAt"`
+	UpdatedBy nuuid.NUUID `json:"updatedBy"`
+	DeletedBy nuuid.NUUID `json:"deletedBy"`
+}
+
+// NewFromPayload creates a new User. Users always register as Regular, only
+// an Admin can change their role afterwards, and must pick a password to log
+// in with.
+func (u User) NewFromPayload(payload PayloadUser) (User, error) {
+	if payload.Password == "" {
+		return User{}, failure.BadRequestFromString("password is required")
+	}
+
+	userId, _ := uuid.NewV4()
+	newUser := User{
+		ID:        userId,
+		Username:  payload.Username,
+		Email:     payload.Email,
+		Role:      Regular,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	err := newUser.SetPassword(payload.Password)
+	if err != nil {
+		return User{}, err
+	}
+
+	err = newUser.Validate()
+	return newUser, err
+}
+
+func (u *User) ToResponseFormat() UserResponseFormat {
+	resp := UserResponseFormat{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Role:      u.Role.String(),
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+		DeletedAt: u.DeletedAt,
+		UpdatedBy: u.UpdatedBy,
+		DeletedBy: u.DeletedBy,
+	}
+	return resp
+}
+
+func (u *User) Validate() error {
+	validator := shared.GetValidator()
+	return validator.Struct(u)
+}
+
+func (u User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.ToResponseFormat())
+}
+
+func (u *User) IsDeleted() (deleted bool) {
+	return u.DeletedAt.Valid && u.DeletedBy.Valid
+}
+
+func (u *User) SoftDelete(userID uuid.UUID) (err error) {
+	if u.IsDeleted() {
+		return failure.Conflict("softDelete", "User", "already marked as deleted")
+	}
+
+	u.DeletedAt = null.TimeFrom(time.Now().UTC())
+	u.DeletedBy = nuuid.From(userID)
+	return
+}
+
+// SetPassword stores the bcrypt hash of password.
+func (u *User) SetPassword(password string) (err error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return failure.InternalError(err)
+	}
+
+	u.Password = string(hash)
+	return
+}
+
+// Update changes the username, email and, when given, the password. The role
+// is left alone, use ChangeRole for that.
+func (u *User) Update(req PayloadUser, userID uuid.UUID) (err error) {
+	if u.IsDeleted() {
+		return failure.Conflict("update", "User", "already marked as deleted")
+	}
+
+	if req.Password != "" {
+		err = u.SetPassword(req.Password)
+		if err != nil {
+			return
+		}
+	}
+
+	u.Username = req.Username
+	u.Email = req.Email
+	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedBy = nuuid.From(userID)
+
+	err = u.Validate()
+	return
+}
+
+func (u *User) ChangeRole(req PayloadUserRole, userID uuid.UUID) (err error) {
+	if u.IsDeleted() {
+		return failure.Conflict("changeRole", "User", "already marked as deleted")
+	}
+
+	role, err := ParseRole(req.Role)
+	if err != nil {
+		return failure.BadRequest(err)
+	}
+
+	u.Role = role
+	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedBy = nuuid.From(userID)
+	return
 }
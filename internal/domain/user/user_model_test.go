@@ -0,0 +1,59 @@
+package user_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			in      string
+			want    user.Role
+			wantErr bool
+		}{
+			{name: "admin", in: "admin", want: user.Admin},
+			{name: "regular", in: "regular", want: user.Regular},
+			{name: "unknown", in: "root", want: user.Regular, wantErr: true},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				got, err := user.ParseRole(test.in)
+				assert.Equal(t, test.wantErr, err != nil)
+				assert.Equal(t, test.want, got)
+			})
+		}
+	})
+
+	t.Run("scan and value", func(t *testing.T) {
+		var role user.Role
+		assert.NoError(t, role.Scan([]byte("admin")))
+		assert.Equal(t, user.Admin, role)
+
+		value, err := user.Regular.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "regular", value)
+	})
+
+	t.Run("new user defaults to regular", func(t *testing.T) {
+		u, err := user.User{}.NewFromPayload(user.PayloadUser{Username: "jane", Email: "jane@example.com", Password: "secret-password"})
+		assert.NoError(t, err)
+		assert.Equal(t, user.Regular, u.Role)
+		assert.NotEqual(t, "secret-password", u.Password)
+	})
+
+	t.Run("new user cannot pick its role", func(t *testing.T) {
+		var payload user.PayloadUser
+		err := json.Unmarshal([]byte(`{"username":"jane","email":"jane@example.com","password":"secret-password","role":"admin"}`), &payload)
+		assert.NoError(t, err)
+
+		u, err := user.User{}.NewFromPayload(payload)
+		assert.NoError(t, err)
+		assert.Equal(t, user.Regular, u.Role)
+	})
+}
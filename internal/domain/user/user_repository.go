@@ -1 +1,145 @@
 package user
+
+import (
+	"database/sql"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	userQueries = struct {
+		selectUser string
+		insertUser string
+		updateUser string
+	}{
+		selectUser: `
+			SELECT
+				user_id,
+				username,
+				email,
+				role,
+				password,
+				created_at,
+				updated_at,
+				deleted_at,
+				updated_by,
+				deleted_by
+			FROM user`,
+
+		insertUser: `
+			INSERT INTO user (user_id, username, email, role, password, created_at, updated_at)
+			VALUES (:user_id, :username, :email, :role, :password, :created_at, :updated_at)`,
+
+		updateUser: `
+			UPDATE user
+			SET
+				username = :username,
+				email = :email,
+				role = :role,
+				password = :password,
+				updated_at = :updated_at,
+				updated_by = :updated_by,
+				deleted_at = :deleted_at,
+				deleted_by = :deleted_by
+			WHERE user_id = :user_id`,
+	}
+)
+
+type UserRepository interface {
+	Create(user User) (err error)
+	GetUserByID(userId uuid.UUID) (user User, err error)
+	ExistsByUsernameOrEmail(username, email string, exclude uuid.UUID) (exists bool, err error)
+	Update(user User) (err error)
+}
+
+type UserRepositoryMariaDB struct {
+	DB *infras.MariaDBConn
+}
+
+func ProvideUserRepositoryMariaDB(db *infras.MariaDBConn) *UserRepositoryMariaDB {
+	s := new(UserRepositoryMariaDB)
+	s.DB = db
+	return s
+}
+
+func (r *UserRepositoryMariaDB) Create(user User) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txCreate(tx, user); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+func (r *UserRepositoryMariaDB) GetUserByID(userId uuid.UUID) (user User, err error) {
+	err = r.DB.Read.Get(&user, userQueries.selectUser+" WHERE user_id = ?", userId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("user")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+// ExistsByUsernameOrEmail checks whether another user, other than exclude,
+// already uses the username or the email.
+func (r *UserRepositoryMariaDB) ExistsByUsernameOrEmail(username, email string, exclude uuid.UUID) (exists bool, err error) {
+	err = r.DB.Read.Get(
+		&exists,
+		"SELECT EXISTS(SELECT 1 FROM user WHERE (username = ? OR email = ?) AND user_id <> ?)",
+		username, email, exclude.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *UserRepositoryMariaDB) Update(user User) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txUpdate(tx, user); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+func (r *UserRepositoryMariaDB) txCreate(tx *sqlx.Tx, user User) (err error) {
+	stmt, err := tx.PrepareNamed(userQueries.insertUser)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(user)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *UserRepositoryMariaDB) txUpdate(tx *sqlx.Tx, user User) (err error) {
+	stmt, err := tx.PrepareNamed(userQueries.updateUser)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(user)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
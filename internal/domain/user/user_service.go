@@ -0,0 +1,115 @@
+package user
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+)
+
+type UserService interface {
+	Register(payload PayloadUser) (user User, err error)
+	GetUserByID(userId uuid.UUID) (user User, err error)
+	Update(userId uuid.UUID, payload PayloadUser, updatedBy uuid.UUID) (user User, err error)
+	ChangeRole(userId uuid.UUID, payload PayloadUserRole, updatedBy uuid.UUID) (user User, err error)
+	SoftDelete(userId uuid.UUID, deletedBy uuid.UUID) (user User, err error)
+	ResolveActiveUser(userId uuid.UUID) (user User, err error)
+}
+
+type UserServiceImpl struct {
+	UserRepository UserRepository
+	Config         *configs.Config
+}
+
+func ProvideUserServiceImpl(userRepo UserRepository, config *configs.Config) *UserServiceImpl {
+	s := new(UserServiceImpl)
+	s.UserRepository = userRepo
+	s.Config = config
+
+	return s
+}
+
+func (s *UserServiceImpl) Register(payload PayloadUser) (user User, err error) {
+	user, err = user.NewFromPayload(payload)
+	if err != nil {
+		return
+	}
+
+	exists, err := s.UserRepository.ExistsByUsernameOrEmail(user.Username, user.Email, user.ID)
+	if err != nil {
+		return
+	}
+	if exists {
+		err = failure.Conflict("register", "User", "username or email already taken")
+		return
+	}
+
+	err = s.UserRepository.Create(user)
+	return
+}
+
+func (s *UserServiceImpl) GetUserByID(userId uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.GetUserByID(userId)
+	return
+}
+
+func (s *UserServiceImpl) Update(userId uuid.UUID, payload PayloadUser, updatedBy uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.GetUserByID(userId)
+	if err != nil {
+		return
+	}
+
+	exists, err := s.UserRepository.ExistsByUsernameOrEmail(payload.Username, payload.Email, user.ID)
+	if err != nil {
+		return
+	}
+	if exists {
+		err = failure.Conflict("update", "User", "username or email already taken")
+		return
+	}
+
+	err = user.Update(payload, updatedBy)
+	if err != nil {
+		return
+	}
+	err = s.UserRepository.Update(user)
+	return
+}
+
+func (s *UserServiceImpl) ChangeRole(userId uuid.UUID, payload PayloadUserRole, updatedBy uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.GetUserByID(userId)
+	if err != nil {
+		return
+	}
+	err = user.ChangeRole(payload, updatedBy)
+	if err != nil {
+		return
+	}
+	err = s.UserRepository.Update(user)
+	return
+}
+
+func (s *UserServiceImpl) SoftDelete(userId uuid.UUID, deletedBy uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.GetUserByID(userId)
+	if err != nil {
+		return
+	}
+	err = user.SoftDelete(deletedBy)
+	if err != nil {
+		return
+	}
+	err = s.UserRepository.Update(user)
+	return
+}
+
+// ResolveActiveUser resolves a User that is allowed to act on other domains,
+// meaning it exists and has not been soft deleted.
+func (s *UserServiceImpl) ResolveActiveUser(userId uuid.UUID) (user User, err error) {
+	user, err = s.UserRepository.GetUserByID(userId)
+	if err != nil {
+		return
+	}
+	if user.IsDeleted() {
+		err = failure.NotFound("user")
+	}
+	return
+}
@@ -0,0 +1,187 @@
+package variants
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/gofrs/uuid"
+)
+
+// ReservationStatus is the state of a Reservation. Reservations start out as
+// ReservationReserved and end up in one of the other states.
+type ReservationStatus string
+
+const (
+	ReservationReserved  ReservationStatus = "reserved"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// Reservation holds Quantity units of a Variant taken out of a warehouse for
+// the client that reserved them, until they are committed or released.
+type Reservation struct {
+	ReservationID uuid.UUID         `db:"reservation_id"`
+	VariantID     uuid.UUID         `db:"variant_id"`
+	WarehouseID   int               `db:"warehouse_id"`
+	Quantity      int               `db:"quantity" validate:"min=1"`
+	Status        ReservationStatus `db:"status"`
+	ClientID      string            `db:"client_id" validate:"required"`
+	ExpiresAt     time.Time         `db:"expires_at"`
+	CreatedAt     time.Time         `db:"created_at"`
+	UpdatedAt     time.Time         `db:"updated_at"`
+}
+
+// PayloadReservation reserves Quantity units of a Variant. Stock is taken from
+// WarehouseID, or from the warehouse holding the most units when it is 0.
+type PayloadReservation struct {
+	VariantID   uuid.UUID `json:"variantId" validate:"required"`
+	WarehouseID int       `json:"warehouseId" validate:"min=0"`
+	Quantity    int       `json:"quantity" validate:"required,min=1"`
+}
+
+type ReservationResponseFormat struct {
+	ReservationID uuid.UUID         `json:"reservationId"`
+	VariantID     uuid.UUID         `json:"variantId"`
+	WarehouseID   int               `json:"warehouseId"`
+	Quantity      int               `json:"quantity"`
+	Status        ReservationStatus `json:"status"`
+	ExpiresAt     time.Time         `json:"expiresAt"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+// NewFromPayload creates a new Reservation for clientID, expiring after ttl.
+func (r Reservation) NewFromPayload(payload PayloadReservation, clientID string, ttl time.Duration) (Reservation, error) {
+	resId, _ := uuid.NewV4()
+	now := time.Now().UTC()
+	newRes := Reservation{
+		ReservationID: resId,
+		VariantID:     payload.VariantID,
+		WarehouseID:   payload.WarehouseID,
+		Quantity:      payload.Quantity,
+		Status:        ReservationReserved,
+		ClientID:      clientID,
+		ExpiresAt:     now.Add(ttl),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err := newRes.Validate()
+	return newRes, err
+}
+
+// IsExpired reports whether a pending Reservation outlived its TTL.
+func (r *Reservation) IsExpired(now time.Time) bool {
+	return r.Status == ReservationReserved && !now.Before(r.ExpiresAt)
+}
+
+// Commit marks the Reservation as committed. Expired Reservations can only be
+// released.
+func (r *Reservation) Commit(now time.Time) (err error) {
+	if r.Status != ReservationReserved {
+		return failure.Conflict("commit", "Reservation", "is already "+string(r.Status))
+	}
+	if r.IsExpired(now) {
+		return failure.Conflict("commit", "Reservation", "has expired")
+	}
+
+	r.Status = ReservationCommitted
+	r.UpdatedAt = now
+	return
+}
+
+// Release marks the Reservation as released, or as expired when status is
+// ReservationExpired, so that its stock can be put back.
+func (r *Reservation) Release(status ReservationStatus, now time.Time) (err error) {
+	if r.Status != ReservationReserved {
+		return failure.Conflict("release", "Reservation", "is already "+string(r.Status))
+	}
+
+	r.Status = status
+	r.UpdatedAt = now
+	return
+}
+
+func (r *Reservation) Validate() error {
+	validator := shared.GetValidator()
+	return validator.Struct(r)
+}
+
+func (r *Reservation) ToResponseFormat() ReservationResponseFormat {
+	return ReservationResponseFormat{
+		ReservationID: r.ReservationID,
+		VariantID:     r.VariantID,
+		WarehouseID:   r.WarehouseID,
+		Quantity:      r.Quantity,
+		Status:        r.Status,
+		ExpiresAt:     r.ExpiresAt,
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+}
+
+func (r Reservation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.ToResponseFormat())
+}
+
+// MovementKind is the reason of a StockMovement.
+type MovementKind string
+
+const (
+	MovementAssign  MovementKind = "assign"
+	MovementAdjust  MovementKind = "adjust"
+	MovementMove    MovementKind = "move"
+	MovementReserve MovementKind = "reserve"
+	MovementCommit  MovementKind = "commit"
+	MovementRelease MovementKind = "release"
+	MovementExpire  MovementKind = "expire"
+)
+
+// StockMovement is an entry of the stock_movement ledger. Quantity is the
+// signed change to the stock of the Variant in the warehouse.
+type StockMovement struct {
+	VariantID     uuid.UUID    `db:"variant_id"`
+	WarehouseID   int          `db:"warehouse_id"`
+	ReservationID nuuid.NUUID  `db:"reservation_id"`
+	Kind          MovementKind `db:"kind"`
+	Quantity      int          `db:"quantity"`
+	CreatedAt     time.Time    `db:"created_at"`
+}
+
+// NewStockMovement records a change of quantity units to the stock of a
+// Variant in a warehouse.
+func NewStockMovement(kind MovementKind, variantId uuid.UUID, warehouseId int, quantity int) StockMovement {
+	return StockMovement{
+		VariantID:   variantId,
+		WarehouseID: warehouseId,
+		Kind:        kind,
+		Quantity:    quantity,
+		CreatedAt:   time.Now().UTC(),
+	}
+}
+
+// movement records the stock change of the Reservation after a transition.
+func (r *Reservation) movement() StockMovement {
+	var m StockMovement
+	switch r.Status {
+	case ReservationReserved:
+		m = NewStockMovement(MovementReserve, r.VariantID, r.WarehouseID, -r.Quantity)
+	case ReservationCommitted:
+		m = NewStockMovement(MovementCommit, r.VariantID, r.WarehouseID, 0)
+	case ReservationReleased:
+		m = NewStockMovement(MovementRelease, r.VariantID, r.WarehouseID, r.Quantity)
+	case ReservationExpired:
+		m = NewStockMovement(MovementExpire, r.VariantID, r.WarehouseID, r.Quantity)
+	}
+	m.ReservationID = nuuid.From(r.ReservationID)
+	return m
+}
+
+// InsertMovementQuery appends a StockMovement to the ledger.
+const InsertMovementQuery = `
+	INSERT INTO stock_movement (variant_id, warehouse_id, reservation_id, kind, quantity, created_at)
+	VALUES (:variant_id, :warehouse_id, :reservation_id, :kind, :quantity, :created_at)`
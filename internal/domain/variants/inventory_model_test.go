@@ -0,0 +1,39 @@
+package variants_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservationTransitions(t *testing.T) {
+	variantID, _ := uuid.NewV4()
+	payload := variants.PayloadReservation{VariantID: variantID, Quantity: 2}
+
+	res, err := variants.Reservation{}.NewFromPayload(payload, "order-service", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, variants.ReservationReserved, res.Status)
+
+	now := time.Now().UTC()
+	assert.NoError(t, res.Commit(now))
+	assert.Equal(t, variants.ReservationCommitted, res.Status)
+	assert.Error(t, res.Commit(now))
+	assert.Error(t, res.Release(variants.ReservationReleased, now))
+}
+
+func TestReservationExpiry(t *testing.T) {
+	variantID, _ := uuid.NewV4()
+	payload := variants.PayloadReservation{VariantID: variantID, Quantity: 2}
+
+	res, err := variants.Reservation{}.NewFromPayload(payload, "order-service", time.Minute)
+	assert.NoError(t, err)
+
+	later := res.ExpiresAt
+	assert.True(t, res.IsExpired(later))
+	assert.Error(t, res.Commit(later))
+	assert.NoError(t, res.Release(variants.ReservationExpired, later))
+	assert.False(t, res.IsExpired(later))
+}
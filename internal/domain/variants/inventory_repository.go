@@ -0,0 +1,266 @@
+package variants
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	inventoryQueries = struct {
+		selectReservation string
+		insertReservation string
+		updateReservation string
+		selectCandidates  string
+		takeStock         string
+		putBackStock      string
+		selectExpired     string
+	}{
+		selectReservation: `
+			SELECT
+				reservation_id,
+				variant_id,
+				warehouse_id,
+				quantity,
+				status,
+				client_id,
+				expires_at,
+				created_at,
+				updated_at
+			FROM stock_reservation`,
+
+		insertReservation: `
+			INSERT INTO stock_reservation (reservation_id, variant_id, warehouse_id, quantity, status, client_id, expires_at, created_at, updated_at)
+			VALUES (:reservation_id, :variant_id, :warehouse_id, :quantity, :status, :client_id, :expires_at, :created_at, :updated_at)`,
+
+		// updateReservation only moves pending reservations forward, so that
+		// concurrent commits, releases and sweeps cannot settle one twice.
+		updateReservation: `
+			UPDATE stock_reservation
+			SET status = ?, updated_at = ?
+			WHERE reservation_id = ? AND status = 'reserved'`,
+
+		selectCandidates: `
+			SELECT vl.warehouse_id
+			FROM variant_location vl
+			JOIN warehouse w ON w.warehouse_id = vl.warehouse_id
+			WHERE vl.variant_id = ? AND vl.variant_quantity >= ? AND w.deleted_at IS NULL
+			ORDER BY vl.variant_quantity DESC, vl.warehouse_id`,
+
+		// takeStock never lets the stock of a warehouse drop below zero, no
+		// matter how many reservations race for it.
+		takeStock: `
+			UPDATE variant_location
+			SET variant_quantity = variant_quantity - ?
+			WHERE variant_id = ? AND warehouse_id = ? AND variant_quantity >= ?`,
+
+		putBackStock: `
+			INSERT INTO variant_location (warehouse_id, variant_id, variant_quantity)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE variant_quantity = variant_quantity + VALUES(variant_quantity)`,
+
+		selectExpired: `
+			SELECT
+				reservation_id,
+				variant_id,
+				warehouse_id,
+				quantity,
+				status,
+				client_id,
+				expires_at,
+				created_at,
+				updated_at
+			FROM stock_reservation
+			WHERE status = 'reserved' AND expires_at <= ?
+			ORDER BY expires_at, reservation_id
+			LIMIT ? OFFSET ?`,
+	}
+)
+
+type InventoryRepository interface {
+	Reserve(res Reservation, limitedThreshold int) (reserved Reservation, err error)
+	GetReservationByID(resId uuid.UUID) (res Reservation, err error)
+	GetExpiredReservations(now time.Time, limit, offset int) (ress []Reservation, err error)
+	Commit(res Reservation) (err error)
+	Release(res Reservation, limitedThreshold int) (err error)
+}
+
+type InventoryRepositoryMariaDB struct {
+	DB *infras.MariaDBConn
+}
+
+func ProvideInventoryRepositoryMariaDB(db *infras.MariaDBConn) *InventoryRepositoryMariaDB {
+	return &InventoryRepositoryMariaDB{
+		DB: db,
+	}
+}
+
+// Reserve takes the stock of a Reservation out of its warehouse, or out of the
+// first warehouse holding enough of it when none is set, and saves it.
+func (r *InventoryRepositoryMariaDB) Reserve(res Reservation, limitedThreshold int) (reserved Reservation, err error) {
+	err = r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		warehouseId, err := r.txTakeStock(tx, res)
+		if err != nil {
+			c <- err
+			return
+		}
+		res.WarehouseID = warehouseId
+
+		if _, err := tx.NamedExec(inventoryQueries.insertReservation, res); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		if err := r.txRecord(tx, res, limitedThreshold); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+	if err != nil {
+		return
+	}
+
+	reserved = res
+	return
+}
+
+// txTakeStock decrements the stock of a warehouse by the quantity of res and
+// returns the warehouse it was taken from.
+func (r *InventoryRepositoryMariaDB) txTakeStock(tx *sqlx.Tx, res Reservation) (warehouseId int, err error) {
+	candidates := []int{res.WarehouseID}
+	if res.WarehouseID == 0 {
+		candidates = nil
+		err = tx.Select(&candidates, inventoryQueries.selectCandidates, res.VariantID.String(), res.Quantity)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+
+	for _, candidate := range candidates {
+		result, err := tx.Exec(inventoryQueries.takeStock, res.Quantity, res.VariantID.String(), candidate, res.Quantity)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return 0, err
+		}
+		if affected == 1 {
+			return candidate, nil
+		}
+	}
+
+	err = failure.Conflict("reserve", "stock", "insufficient quantity in warehouse")
+	return
+}
+
+func (r *InventoryRepositoryMariaDB) GetReservationByID(resId uuid.UUID) (res Reservation, err error) {
+	err = r.DB.Read.Get(&res, inventoryQueries.selectReservation+" WHERE reservation_id = ?", resId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("reservation")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// GetExpiredReservations returns up to limit pending Reservations which
+// expired by now, the oldest first, skipping the first offset of them.
+func (r *InventoryRepositoryMariaDB) GetExpiredReservations(now time.Time, limit, offset int) (ress []Reservation, err error) {
+	err = r.DB.Read.Select(&ress, inventoryQueries.selectExpired, now, limit, offset)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// Commit settles a Reservation. Its stock was already taken out when it was
+// reserved.
+func (r *InventoryRepositoryMariaDB) Commit(res Reservation) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txSettle(tx, res); err != nil {
+			c <- err
+			return
+		}
+		if _, err := tx.NamedExec(InsertMovementQuery, res.movement()); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+// Release puts the stock of a released or expired Reservation back into its
+// warehouse.
+func (r *InventoryRepositoryMariaDB) Release(res Reservation, limitedThreshold int) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txSettle(tx, res); err != nil {
+			c <- err
+			return
+		}
+		if _, err := tx.Exec(inventoryQueries.putBackStock, res.WarehouseID, res.VariantID.String(), res.Quantity); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		if err := r.txRecord(tx, res, limitedThreshold); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+// txSettle saves the new status of a pending Reservation, failing with a
+// Conflict when it was settled in the meantime.
+func (r *InventoryRepositoryMariaDB) txSettle(tx *sqlx.Tx, res Reservation) (err error) {
+	result, err := tx.Exec(inventoryQueries.updateReservation, res.Status, res.UpdatedAt, res.ReservationID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	if affected == 0 {
+		err = failure.Conflict("settle", "Reservation", "was already settled")
+	}
+	return
+}
+
+// txRecord appends the stock change of res to the ledger and syncs the
+// quantity and status of its Variant.
+func (r *InventoryRepositoryMariaDB) txRecord(tx *sqlx.Tx, res Reservation, limitedThreshold int) (err error) {
+	_, err = tx.NamedExec(InsertMovementQuery, res.movement())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	_, err = tx.Exec(SyncQuantityQuery, res.VariantID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	_, err = tx.Exec(SyncStatusQuery+" WHERE variant.variant_id = ?", limitedThreshold, res.VariantID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
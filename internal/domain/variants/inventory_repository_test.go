@@ -0,0 +1,101 @@
+package variants_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newInventoryRepository(t *testing.T) (*variants.InventoryRepositoryMariaDB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	conn := sqlx.NewDb(db, "mysql")
+	return variants.ProvideInventoryRepositoryMariaDB(&infras.MariaDBConn{Read: conn, Write: conn}), mock
+}
+
+func newReservation(t *testing.T, warehouseId int) variants.Reservation {
+	variantID, _ := uuid.NewV4()
+	res, err := variants.Reservation{}.NewFromPayload(variants.PayloadReservation{VariantID: variantID, WarehouseID: warehouseId, Quantity: 2}, "order-service", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+var (
+	takeStock         = regexp.QuoteMeta("SET variant_quantity = variant_quantity - ?") + `\s+` + regexp.QuoteMeta("WHERE variant_id = ? AND warehouse_id = ? AND variant_quantity >= ?")
+	insertReservation = regexp.QuoteMeta("INSERT INTO stock_reservation")
+	updateReservation = regexp.QuoteMeta("UPDATE stock_reservation") + `[\s\S]+` + regexp.QuoteMeta("AND status = 'reserved'")
+	insertMovement    = regexp.QuoteMeta("INSERT INTO stock_movement")
+	syncQuantity      = regexp.QuoteMeta("SET quantity = (")
+	syncStatus        = regexp.QuoteMeta("SET variant.status = CASE")
+)
+
+func TestReserveTakesStockConditionally(t *testing.T) {
+	t.Run("takes stock", func(t *testing.T) {
+		repo, mock := newInventoryRepository(t)
+		res := newReservation(t, 7)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(takeStock).
+			WithArgs(2, res.VariantID.String(), 7, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertReservation).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertMovement).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(syncQuantity).WithArgs(res.VariantID.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(syncStatus).WithArgs(5, res.VariantID.String()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		reserved, err := repo.Reserve(res, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, reserved.WarehouseID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fails without enough stock", func(t *testing.T) {
+		repo, mock := newInventoryRepository(t)
+		res := newReservation(t, 7)
+
+		// Another reservation took the stock first: the guarded update
+		// changes nothing.
+		mock.ExpectBegin()
+		mock.ExpectExec(takeStock).
+			WithArgs(2, res.VariantID.String(), 7, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.Reserve(res, 5)
+		assert.Equal(t, http.StatusConflict, failure.GetCode(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSettleTwiceConflicts(t *testing.T) {
+	repo, mock := newInventoryRepository(t)
+	res := newReservation(t, 7)
+	assert.NoError(t, res.Commit(time.Now().UTC()))
+
+	// The reservation was released concurrently, so it is no longer pending.
+	mock.ExpectBegin()
+	mock.ExpectExec(updateReservation).
+		WithArgs(variants.ReservationCommitted, res.UpdatedAt, res.ReservationID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Commit(res)
+	assert.Equal(t, http.StatusConflict, failure.GetCode(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
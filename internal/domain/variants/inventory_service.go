@@ -0,0 +1,133 @@
+package variants
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// sweepBatchSize is the number of expired Reservations released per query of
+// ReleaseExpired.
+const sweepBatchSize = 100
+
+// InventoryService sells the stock of Variants through Reservations: reserved
+// stock is taken out of a warehouse right away, then either committed once
+// sold or released back.
+type InventoryService interface {
+	Reserve(payload PayloadReservation, clientID string) (res Reservation, err error)
+	GetReservationByID(resId uuid.UUID, clientID string) (res Reservation, err error)
+	Commit(resId uuid.UUID, clientID string) (res Reservation, err error)
+	Release(resId uuid.UUID, clientID string) (res Reservation, err error)
+	ReleaseExpired() (released int, err error)
+}
+
+type InventoryServiceImpl struct {
+	InventoryRepository InventoryRepository
+	VariantRepository   VariantRepository
+	Config              *configs.Config
+}
+
+func ProvideInventoryServiceImpl(inventoryRepo InventoryRepository, variantRepo VariantRepository, config *configs.Config) *InventoryServiceImpl {
+	s := new(InventoryServiceImpl)
+	s.InventoryRepository = inventoryRepo
+	s.VariantRepository = variantRepo
+	s.Config = config
+
+	return s
+}
+
+func (s *InventoryServiceImpl) Reserve(payload PayloadReservation, clientID string) (res Reservation, err error) {
+	vari, err := s.VariantRepository.GetVariantByID(payload.VariantID)
+	if err != nil {
+		return
+	}
+	if vari.IsDeleted() {
+		err = failure.Conflict("reserve", "Variant", "is marked as deleted")
+		return
+	}
+
+	ttl := time.Duration(s.Config.Inventory.ReservationTTLSeconds) * time.Second
+	res, err = res.NewFromPayload(payload, clientID, ttl)
+	if err != nil {
+		return
+	}
+	res, err = s.InventoryRepository.Reserve(res, s.Config.Variant.LimitedThreshold)
+	return
+}
+
+// GetReservationByID resolves a Reservation of the client. Reservations of
+// other clients are reported as not found.
+func (s *InventoryServiceImpl) GetReservationByID(resId uuid.UUID, clientID string) (res Reservation, err error) {
+	res, err = s.InventoryRepository.GetReservationByID(resId)
+	if err != nil {
+		return
+	}
+	if res.ClientID != clientID {
+		err = failure.NotFound("reservation")
+	}
+	return
+}
+
+func (s *InventoryServiceImpl) Commit(resId uuid.UUID, clientID string) (res Reservation, err error) {
+	res, err = s.GetReservationByID(resId, clientID)
+	if err != nil {
+		return
+	}
+	err = res.Commit(time.Now().UTC())
+	if err != nil {
+		return
+	}
+	err = s.InventoryRepository.Commit(res)
+	return
+}
+
+func (s *InventoryServiceImpl) Release(resId uuid.UUID, clientID string) (res Reservation, err error) {
+	res, err = s.GetReservationByID(resId, clientID)
+	if err != nil {
+		return
+	}
+	err = res.Release(ReservationReleased, time.Now().UTC())
+	if err != nil {
+		return
+	}
+	err = s.InventoryRepository.Release(res, s.Config.Variant.LimitedThreshold)
+	return
+}
+
+// ReleaseExpired puts the stock of every expired Reservation back. Reservations
+// settled concurrently, by a client or another sweeper, are skipped. A
+// Reservation failing to be released is logged and left for the next sweep,
+// without holding back the ones expiring after it.
+func (s *InventoryServiceImpl) ReleaseExpired() (released int, err error) {
+	failed := 0
+	for {
+		now := time.Now().UTC()
+		ress, err := s.InventoryRepository.GetExpiredReservations(now, sweepBatchSize, failed)
+		if err != nil {
+			return released, err
+		}
+
+		for _, res := range ress {
+			err = res.Release(ReservationExpired, now)
+			if err == nil {
+				err = s.InventoryRepository.Release(res, s.Config.Variant.LimitedThreshold)
+			}
+			if err != nil {
+				if failure.GetCode(err) != http.StatusConflict {
+					log.Error().Err(err).Str("reservationId", res.ReservationID.String()).Msg("Failed to release expired reservation.")
+					failed++
+				}
+				continue
+			}
+			released++
+		}
+
+		if len(ress) < sweepBatchSize {
+			return released, nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package variants_test
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// expiredRepository holds pending reservations in memory, failing to release
+// those listed in errs.
+type expiredRepository struct {
+	variants.InventoryRepository
+	pending []variants.Reservation
+	errs    map[uuid.UUID]error
+}
+
+func (r *expiredRepository) GetExpiredReservations(now time.Time, limit, offset int) (ress []variants.Reservation, err error) {
+	for i, res := range r.pending {
+		if i >= offset && len(ress) < limit {
+			ress = append(ress, res)
+		}
+	}
+	return
+}
+
+func (r *expiredRepository) Release(res variants.Reservation, limitedThreshold int) (err error) {
+	if err = r.errs[res.ReservationID]; err != nil && failure.GetCode(err) != http.StatusConflict {
+		return
+	}
+	for i := range r.pending {
+		if r.pending[i].ReservationID == res.ReservationID {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			break
+		}
+	}
+	return
+}
+
+func TestReleaseExpired(t *testing.T) {
+	repo := &expiredRepository{errs: map[uuid.UUID]error{}}
+	for i := 0; i < 250; i++ {
+		res := newReservation(t, 7)
+		res.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+		repo.pending = append(repo.pending, res)
+	}
+	// The oldest reservation cannot be put back, and another one was
+	// settled concurrently.
+	repo.errs[repo.pending[0].ReservationID] = errors.New("foreign key constraint fails")
+	repo.errs[repo.pending[1].ReservationID] = failure.Conflict("settle", "Reservation", "was already settled")
+
+	s := variants.ProvideInventoryServiceImpl(repo, nil, &configs.Config{})
+	released, err := s.ReleaseExpired()
+	assert.NoError(t, err)
+	assert.Equal(t, 248, released)
+	assert.Len(t, repo.pending, 1)
+}
+
+// countingInventoryService counts the sweeps of a ReservationSweeper.
+type countingInventoryService struct {
+	variants.InventoryService
+	sweeps int32
+}
+
+func (s *countingInventoryService) ReleaseExpired() (released int, err error) {
+	atomic.AddInt32(&s.sweeps, 1)
+	return
+}
+
+func TestReservationSweeper(t *testing.T) {
+	service := &countingInventoryService{}
+	sweeper := &variants.ReservationSweeper{InventoryService: service, Interval: 5 * time.Millisecond}
+
+	sweeper.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&service.sweeps) >= 2 }, time.Second, time.Millisecond)
+	sweeper.Stop()
+
+	// A sweep running as the sweeper stops may still complete.
+	sweeps := atomic.LoadInt32(&service.sweeps)
+	time.Sleep(20 * time.Millisecond)
+	assert.LessOrEqual(t, atomic.LoadInt32(&service.sweeps), sweeps+1)
+
+	disabled := &countingInventoryService{}
+	(&variants.ReservationSweeper{InventoryService: disabled}).Start()
+	time.Sleep(10 * time.Millisecond)
+	assert.Zero(t, atomic.LoadInt32(&disabled.sweeps))
+}
@@ -0,0 +1,69 @@
+package variants
+
+import (
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/rs/zerolog/log"
+)
+
+// ReservationSweeper periodically releases expired Reservations. Several
+// instances may run at once, as Reservations are only ever settled once.
+type ReservationSweeper struct {
+	InventoryService InventoryService
+	Interval         time.Duration
+	stop             chan struct{}
+}
+
+func ProvideReservationSweeper(inventoryService InventoryService, config *configs.Config) *ReservationSweeper {
+	return &ReservationSweeper{
+		InventoryService: inventoryService,
+		Interval:         time.Duration(config.Inventory.SweepIntervalSeconds) * time.Second,
+	}
+}
+
+// Start runs the sweeper in the background until Stop is called. A
+// non-positive interval disables it.
+func (s *ReservationSweeper) Start() {
+	if s.Interval <= 0 {
+		log.Info().Msg("Reservation sweeper disabled.")
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go s.run()
+	log.Info().Dur("interval", s.Interval).Msg("Reservation sweeper started.")
+}
+
+// Stop stops a started sweeper.
+func (s *ReservationSweeper) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *ReservationSweeper) run() {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+func (s *ReservationSweeper) sweep() {
+	released, err := s.InventoryService.ReleaseExpired()
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	if released > 0 {
+		log.Info().Int("released", released).Msg("Released expired reservations.")
+	}
+}
@@ -2,10 +2,12 @@ package variants
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/evermos/boilerplate-go/internal/domain/image"
 	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
@@ -27,6 +29,7 @@ type Variant struct {
 	Status      string        `db:"status"`
 	Quantity    int           `db:"quantity"`
 	Images      []image.Image `db:"-"`
+	Locations   []Location    `db:"-"`
 	CreatedAt   time.Time     `db:"created_at"`
 	UpdatedAt   time.Time     `db:"updated_at"`
 	DeletedAt   null.Time     `db:"deleted_at"`
@@ -35,28 +38,60 @@ type Variant struct {
 	DeletedBy   nuuid.NUUID   `db:"deleted_by"`
 }
 
+// Location is the quantity of a Variant held in a single warehouse, as stored
+// in the variant_location table.
+type Location struct {
+	VariantLocationID int       `db:"variant_location_id"`
+	WarehouseID       int       `db:"warehouse_id"`
+	WarehouseName     string    `db:"warehouse_name"`
+	VariantID         uuid.UUID `db:"variant_id"`
+	Quantity          int       `db:"variant_quantity"`
+
+	// read is the quantity the Location had when it was read, kept once
+	// Variant.Update changes Quantity so that the write can be guarded.
+	read    int
+	changed bool
+}
+
+type LocationResponseFormat struct {
+	WarehouseID   int       `json:"warehouseId"`
+	WarehouseName string    `json:"warehouseName"`
+	VariantID     uuid.UUID `json:"variantId"`
+	Quantity      int       `json:"quantity"`
+}
+
 type PayloadVariant struct {
 	VariantName  string   `json:"variantName"`
 	Price        float64  `json:"price"`
-	Status       string   `json:"status"`
-	Quantity     int      `json:"quantity"`
+	Status       string   `json:"status" validate:"omitempty,oneof=ready out_of_stock limited"`
+	Quantity     int      `json:"quantity" validate:"min=0"`
 	ImagePayload []string `json:"images"`
 }
 
+// PayloadUpdateVariant replaces the editable fields of a Variant. Status is
+// derived from Quantity, so it may be left out.
+type PayloadUpdateVariant struct {
+	VariantName string  `json:"variantName" validate:"required,max=100"`
+	Price       float64 `json:"price" validate:"min=0"`
+	Status      string  `json:"status" validate:"omitempty,oneof=ready out_of_stock limited"`
+	Quantity    int     `json:"quantity" validate:"min=0"`
+}
+
 type VariantResponseFormat struct {
-	VariantID   uuid.UUID   `json:"variantId"`
-	ProductID   uuid.UUID   `json:"productId"`
-	VariantName string      `json:"variantName"`
-	Price       float64     `json:"price"`
-	Status      string      `json:"status"`
-	Quantity    int         `json:"quantity"`
-	Images      []string    `json:"images"`
-	CreatedAt   time.Time   `json:"createdAt"`
-	UpdatedAt   time.Time   `json:"updatedAt"`
-	DeletedAt   null.Time   `json:"deletedAt"`
-	CreatedBy   uuid.UUID   `json:"createdBy"`
-	UpdatedBy   uuid.UUID   `json:"updatedBy"`
-	DeletedBy   nuuid.NUUID `json:"deletedBy"`
+	VariantID   uuid.UUID                   `json:"variantId"`
+	ProductID   uuid.UUID                   `json:"productId"`
+	VariantName string                      `json:"variantName"`
+	Price       float64                     `json:"price"`
+	Status      string                      `json:"status"`
+	Quantity    int                         `json:"quantity"`
+	Images      []image.ImageResponseFormat `json:"images"`
+	Stock       []LocationResponseFormat    `json:"stock"`
+	CreatedAt   time.Time                   `json:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt"`
+	DeletedAt   null.Time                   `json:"deletedAt"`
+	CreatedBy   uuid.UUID                   `json:"createdBy"`
+	UpdatedBy   uuid.UUID                   `json:"updatedBy"`
+	DeletedBy   nuuid.NUUID                 `json:"deletedBy"`
 }
 
 func GetVariantStatus(stat VariantStatus) string {
@@ -73,18 +108,79 @@ func GetVariantStatus(stat VariantStatus) string {
 	}
 }
 
+// LimitedThreshold returns the quantity from which Variants of a Product are
+// limited: the Product's own threshold if it has one, the global one otherwise.
+func LimitedThreshold(productThreshold null.Int, globalThreshold int) int {
+	if productThreshold.Valid {
+		return int(productThreshold.Int64)
+	}
+	return globalThreshold
+}
+
+// StatusFromQuantity derives the status of a Variant holding quantity units.
+// Variants holding limitedThreshold units or less are Limited, so a threshold
+// of 0 never marks a Variant as Limited.
+func StatusFromQuantity(quantity, limitedThreshold int) VariantStatus {
+	switch {
+	case quantity <= 0:
+		return OutOfStock
+	case quantity <= limitedThreshold:
+		return Limited
+	default:
+		return Ready
+	}
+}
+
+// SyncStatusQuery is the SQL counterpart of StatusFromQuantity. It sets the
+// status of the variant rows selected by the WHERE clause appended by callers
+// from their quantity, binding the global limited threshold first.
+const SyncStatusQuery = `
+	UPDATE variant
+	JOIN product ON product.product_id = variant.product_id
+	SET variant.status = CASE
+		WHEN variant.quantity <= 0 THEN 'out_of_stock'
+		WHEN variant.quantity <= COALESCE(product.limited_threshold, ?) THEN 'limited'
+		ELSE 'ready'
+	END`
+
+// SyncQuantityQuery keeps the quantity of a variant equal to the sum of its
+// stock across warehouses, binding the variant ID.
+const SyncQuantityQuery = `
+	UPDATE variant
+	SET quantity = (
+		SELECT COALESCE(SUM(variant_quantity), 0)
+		FROM variant_location
+		WHERE variant_location.variant_id = variant.variant_id)
+	WHERE variant_id = ?`
+
+// setStatus derives the status of the Variant from its quantity. A requested
+// status, if any, must match the derived one.
+func (v *Variant) setStatus(requested string, limitedThreshold int) (err error) {
+	status := GetVariantStatus(StatusFromQuantity(v.Quantity, limitedThreshold))
+	if requested != "" && requested != status {
+		return failure.BadRequestFromString(fmt.Sprintf("status %s does not match a quantity of %d, expected %s", requested, v.Quantity, status))
+	}
+
+	v.Status = status
+	return
+}
+
 func (v *Variant) ToResponseFormat() VariantResponseFormat {
 
-	var urlsOnly []string
+	imgs := make([]image.ImageResponseFormat, 0, len(v.Images))
 	for i := range v.Images {
-		urlsOnly = append(urlsOnly, v.Images[i].ImageURL)
+		imgs = append(imgs, v.Images[i].ToResponseFormat())
+	}
+	var stock []LocationResponseFormat
+	for i := range v.Locations {
+		stock = append(stock, v.Locations[i].ToResponseFormat())
 	}
 	resp := VariantResponseFormat{
 		VariantID:   v.VariantID,
 		ProductID:   v.ProductID,
 		VariantName: v.VariantName,
 		Price:       v.Price,
-		Quantity:    v.Quantity,
+		Quantity:    v.TotalQuantity(),
 		Status:      v.Status,
 		CreatedAt:   v.CreatedAt,
 		UpdatedAt:   v.UpdatedAt,
@@ -92,17 +188,60 @@ func (v *Variant) ToResponseFormat() VariantResponseFormat {
 		CreatedBy:   v.CreatedBy,
 		UpdatedBy:   v.UpdatedBy,
 		DeletedBy:   v.DeletedBy,
-		Images:      urlsOnly,
+		Images:      imgs,
+		Stock:       stock,
 	}
 	return resp
 }
 
-func (v Variant) NewFromPayload(payload PayloadVariant, proId uuid.UUID) (Variant, error) {
+// AttachLocations attaches the Locations belonging to this Variant.
+func (v *Variant) AttachLocations(locs []Location) {
+	for _, loc := range locs {
+		if loc.VariantID == v.VariantID {
+			v.Locations = append(v.Locations, loc)
+		}
+	}
+}
+
+// TotalQuantity sums the quantity over all warehouse Locations. Variants
+// resolved without their Locations fall back to the quantity column.
+func (v *Variant) TotalQuantity() int {
+	if v.Locations == nil {
+		return v.Quantity
+	}
+	total := 0
+	for _, loc := range v.Locations {
+		total += loc.Quantity
+	}
+	return total
+}
+
+// setQuantity changes the quantity of the Location, remembering the one read.
+func (l *Location) setQuantity(quantity int) {
+	if !l.changed {
+		l.read = l.Quantity
+		l.changed = true
+	}
+	l.Quantity = quantity
+}
+
+func (l *Location) ToResponseFormat() LocationResponseFormat {
+	return LocationResponseFormat{
+		WarehouseID:   l.WarehouseID,
+		WarehouseName: l.WarehouseName,
+		VariantID:     l.VariantID,
+		Quantity:      l.Quantity,
+	}
+}
+
+// NewFromPayload creates a new Variant of a Product. Its status is derived
+// from its quantity and limitedThreshold.
+func (v Variant) NewFromPayload(payload PayloadVariant, proId uuid.UUID, userID uuid.UUID, limitedThreshold int) (Variant, error) {
 	var img image.Image
 	varId, _ := uuid.NewV4()
 	var imgs []image.Image
-	for _, link := range payload.ImagePayload {
-		pay, err := img.NewFromPayload(link, varId)
+	for position, link := range payload.ImagePayload {
+		pay, err := img.NewFromPayload(link, varId, userID, position)
 		if err != nil {
 			return Variant{}, err
 		}
@@ -114,18 +253,69 @@ func (v Variant) NewFromPayload(payload PayloadVariant, proId uuid.UUID) (Varian
 		VariantName: payload.VariantName,
 		Price:       payload.Price,
 		Images:      imgs,
-		Status:      payload.Status,
 		Quantity:    payload.Quantity,
 		CreatedAt:   time.Now().UTC(),
-		CreatedBy:   proId,
+		CreatedBy:   userID,
 		UpdatedAt:   time.Now().UTC(),
-		UpdatedBy:   proId,
+		UpdatedBy:   userID,
 	}
 
-	err := newVar.Validate()
+	err := newVar.setStatus(payload.Status, limitedThreshold)
+	if err != nil {
+		return Variant{}, err
+	}
+	err = newVar.Validate()
 	return newVar, err
 }
 
+func (v *Variant) IsDeleted() (deleted bool) {
+	return v.DeletedAt.Valid && v.DeletedBy.Valid
+}
+
+func (v *Variant) SoftDelete(userID uuid.UUID) (err error) {
+	if v.IsDeleted() {
+		return failure.Conflict("softDelete", "Variant", "already marked as deleted")
+	}
+
+	v.DeletedAt = null.TimeFrom(time.Now().UTC())
+	v.DeletedBy = nuuid.From(userID)
+	return
+}
+
+// Update applies req to the Variant and derives its status from the new
+// quantity. The quantity of a Variant stocked in a single warehouse is set
+// there; a Variant stocked in no or several warehouses can only have its
+// quantity changed through the warehouse stock endpoints.
+func (v *Variant) Update(req PayloadUpdateVariant, userID uuid.UUID, limitedThreshold int) (err error) {
+	if v.IsDeleted() {
+		return failure.Conflict("update", "Variant", "already marked as deleted")
+	}
+
+	if req.Quantity != v.TotalQuantity() {
+		switch len(v.Locations) {
+		case 0:
+			return failure.Conflict("update", "Variant", "is not stocked in any warehouse, assign its stock through /v1/warehouses instead")
+		case 1:
+			v.Locations[0].setQuantity(req.Quantity)
+		default:
+			return failure.Conflict("update", "Variant", "is stocked in several warehouses, move its stock through /v1/warehouses instead")
+		}
+	}
+
+	v.UpdatedAt = time.Now().UTC()
+	v.UpdatedBy = userID
+	v.VariantName = req.VariantName
+	v.Price = req.Price
+	v.Quantity = req.Quantity
+
+	err = v.setStatus(req.Status, limitedThreshold)
+	if err != nil {
+		return
+	}
+	err = v.Validate()
+	return
+}
+
 func (p *Variant) Validate() error {
 	validator := shared.GetValidator()
 	return validator.Struct(p)
@@ -134,3 +324,7 @@ func (p *Variant) Validate() error {
 func (v Variant) MarshalJSON() ([]byte, error) {
 	return json.Marshal(v.ToResponseFormat())
 }
+
+func (l Location) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.ToResponseFormat())
+}
@@ -0,0 +1,78 @@
+package variants_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantUpdate(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	payload := variants.PayloadUpdateVariant{VariantName: "Blue", Price: 10, Quantity: 7}
+
+	single := variants.Variant{Quantity: 3, Locations: []variants.Location{{WarehouseID: 1, Quantity: 3}}}
+	assert.NoError(t, single.Update(payload, userID, 5))
+	assert.Equal(t, 7, single.Quantity)
+	assert.Equal(t, 7, single.Locations[0].Quantity)
+
+	several := variants.Variant{Quantity: 3, Locations: []variants.Location{{WarehouseID: 1, Quantity: 1}, {WarehouseID: 2, Quantity: 2}}}
+	assert.Error(t, several.Update(payload, userID, 5))
+
+	payload.Quantity = 3
+	assert.NoError(t, several.Update(payload, userID, 5))
+	assert.Equal(t, "Blue", several.VariantName)
+
+	none := variants.Variant{Quantity: 3, Locations: []variants.Location{}}
+	err := none.Update(payload, userID, 5)
+	assert.Equal(t, http.StatusConflict, failure.GetCode(err))
+
+	payload.Quantity = 0
+	assert.NoError(t, none.Update(payload, userID, 5))
+	assert.Equal(t, 0, none.Quantity)
+	assert.Equal(t, "out_of_stock", none.Status)
+}
+
+func TestVariantSoftDelete(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	vari := variants.Variant{}
+
+	assert.NoError(t, vari.SoftDelete(userID))
+	assert.True(t, vari.IsDeleted())
+	assert.Error(t, vari.SoftDelete(userID))
+	assert.Error(t, vari.Update(variants.PayloadUpdateVariant{}, userID, 5))
+}
+
+func TestStatusFromQuantity(t *testing.T) {
+	assert.Equal(t, variants.OutOfStock, variants.StatusFromQuantity(0, 5))
+	assert.Equal(t, variants.Limited, variants.StatusFromQuantity(1, 5))
+	assert.Equal(t, variants.Limited, variants.StatusFromQuantity(5, 5))
+	assert.Equal(t, variants.Ready, variants.StatusFromQuantity(6, 5))
+	assert.Equal(t, variants.Ready, variants.StatusFromQuantity(1, 0))
+}
+
+func TestVariantStatusIsDerived(t *testing.T) {
+	userID, _ := uuid.NewV4()
+	prodID, _ := uuid.NewV4()
+
+	vari, err := variants.Variant{}.NewFromPayload(variants.PayloadVariant{VariantName: "Blue", Quantity: 3}, prodID, userID, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "limited", vari.Status)
+	vari.Locations = []variants.Location{{WarehouseID: 1, Quantity: 3}}
+
+	_, err = variants.Variant{}.NewFromPayload(variants.PayloadVariant{VariantName: "Blue", Status: "ready", Quantity: 0}, prodID, userID, 5)
+	assert.Error(t, err)
+
+	err = vari.Update(variants.PayloadUpdateVariant{VariantName: "Blue", Status: "ready", Quantity: 10}, userID, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "ready", vari.Status)
+}
+
+func TestLimitedThreshold(t *testing.T) {
+	assert.Equal(t, 5, variants.LimitedThreshold(null.Int{}, 5))
+	assert.Equal(t, 0, variants.LimitedThreshold(null.IntFrom(0), 5))
+}
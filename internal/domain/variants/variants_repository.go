@@ -1 +1,356 @@
 package variants
+
+import (
+	"database/sql"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/image"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	variantQueries = struct {
+		selectVariant   string
+		countVariant    string
+		selectOwner     string
+		updateVariant   string
+		updateQuantity  string
+		updateLocation  string
+		deleteVariant   string
+		selectImages    string
+		selectLocations string
+	}{
+		selectVariant: `
+			SELECT
+				variant_id,
+				product_id,
+				variant_name,
+				price,
+				status,
+				quantity,
+				created_at,
+				updated_at,
+				deleted_at,
+				created_by,
+				updated_by,
+				deleted_by
+			FROM variant`,
+
+		countVariant: `SELECT COUNT(*) FROM variant`,
+
+		selectOwner: `
+			SELECT product.user_id
+			FROM variant
+			JOIN product ON product.product_id = variant.product_id
+			WHERE variant.variant_id = ?`,
+
+		updateVariant: `
+			UPDATE variant
+			SET
+				variant_name = :variant_name,
+				price = :price,
+				updated_at = :updated_at,
+				updated_by = :updated_by,
+				deleted_at = :deleted_at,
+				deleted_by = :deleted_by
+			WHERE variant_id = :variant_id`,
+
+		updateQuantity: `
+			UPDATE variant
+			SET quantity = :quantity
+			WHERE variant_id = :variant_id`,
+
+		// updateLocation only writes the quantity a Location was read with,
+		// so that stock reserved meanwhile is not put back.
+		updateLocation: `
+			UPDATE variant_location
+			SET variant_quantity = ?
+			WHERE variant_location_id = ? AND variant_quantity = ?`,
+
+		deleteVariant: `DELETE FROM variant WHERE variant_id = ?`,
+
+		selectImages: `SELECT * FROM image WHERE variant_id IN (?) ORDER BY position, created_at, image_id`,
+
+		selectLocations: `
+			SELECT
+				vl.variant_location_id,
+				vl.warehouse_id,
+				w.warehouse_name,
+				vl.variant_id,
+				vl.variant_quantity
+			FROM variant_location vl
+			JOIN warehouse w ON w.warehouse_id = vl.warehouse_id
+			WHERE vl.variant_id IN (?)
+			ORDER BY vl.warehouse_id`,
+	}
+)
+
+type VariantRepository interface {
+	GetVariantsByProductID(prodId uuid.UUID, limit, offset int) (varis []Variant, err error)
+	CountVariantsByProductID(prodId uuid.UUID) (total int, err error)
+	ProductExistsByID(prodId uuid.UUID) (exists bool, err error)
+	GetLimitedThreshold(prodId uuid.UUID) (threshold null.Int, err error)
+	GetVariantByID(variantId uuid.UUID) (vari Variant, err error)
+	GetVariantOwner(variantId uuid.UUID) (ownerID uuid.UUID, err error)
+	Update(vari Variant, globalThreshold int) (err error)
+	HardDelete(variantId uuid.UUID) (err error)
+	SyncDefaultStatuses(globalThreshold int) (err error)
+}
+
+type VariantRepositoryMariaDB struct {
+	DB *infras.MariaDBConn
+}
+
+func ProvideVariantRepositoryMariaDB(db *infras.MariaDBConn) *VariantRepositoryMariaDB {
+	return &VariantRepositoryMariaDB{
+		DB: db,
+	}
+}
+
+// GetVariantsByProductID fetches a page of the live Variants of a Product with
+// their images and stock.
+func (r *VariantRepositoryMariaDB) GetVariantsByProductID(prodId uuid.UUID, limit, offset int) (varis []Variant, err error) {
+	query := variantQueries.selectVariant + " WHERE product_id = ? AND deleted_at IS NULL ORDER BY created_at, variant_id LIMIT ? OFFSET ?"
+	err = r.DB.Read.Select(&varis, query, prodId.String(), limit, offset)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = r.attachDetails(varis)
+	return
+}
+
+func (r *VariantRepositoryMariaDB) CountVariantsByProductID(prodId uuid.UUID) (total int, err error) {
+	err = r.DB.Read.Get(&total, variantQueries.countVariant+" WHERE product_id = ? AND deleted_at IS NULL", prodId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *VariantRepositoryMariaDB) ProductExistsByID(prodId uuid.UUID) (exists bool, err error) {
+	err = r.DB.Read.Get(&exists, "SELECT COUNT(product_id) FROM product WHERE product_id = ?", prodId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// GetLimitedThreshold returns the limited threshold of a Product, which is
+// null for Products using the global threshold.
+func (r *VariantRepositoryMariaDB) GetLimitedThreshold(prodId uuid.UUID) (threshold null.Int, err error) {
+	err = r.DB.Read.Get(&threshold, "SELECT limited_threshold FROM product WHERE product_id = ?", prodId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("product")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// GetVariantByID resolves a Variant, soft deleted or not, with its images and
+// stock.
+func (r *VariantRepositoryMariaDB) GetVariantByID(variantId uuid.UUID) (vari Variant, err error) {
+	err = r.DB.Read.Get(&vari, variantQueries.selectVariant+" WHERE variant_id = ?", variantId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("variant")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	varis := []Variant{vari}
+	err = r.attachDetails(varis)
+	vari = varis[0]
+	return
+}
+
+// GetVariantOwner returns the ID of the user owning the Product of a Variant.
+func (r *VariantRepositoryMariaDB) GetVariantOwner(variantId uuid.UUID) (ownerID uuid.UUID, err error) {
+	err = r.DB.Read.Get(&ownerID, variantQueries.selectOwner, variantId.String())
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("variant")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// Update saves a Variant along with the quantities of its stock Locations,
+// recording their changes in the stock_movement ledger.
+func (r *VariantRepositoryMariaDB) Update(vari Variant, globalThreshold int) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := TxUpdate(tx, vari, globalThreshold); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+// TxUpdate saves a Variant and the quantities of its stock Locations within
+// tx. It is shared with the product import, which updates Variants in the
+// same transaction as their Products. The quantity of a stocked Variant is
+// summed from its Locations, and its status derived again, globalThreshold
+// being the limited threshold of Products without their own.
+func TxUpdate(tx *sqlx.Tx, vari Variant, globalThreshold int) (err error) {
+	_, err = tx.NamedExec(variantQueries.updateVariant, vari)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if len(vari.Locations) == 0 {
+		_, err = tx.NamedExec(variantQueries.updateQuantity, vari)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+	for _, loc := range vari.Locations {
+		if !loc.changed || loc.Quantity == loc.read {
+			continue
+		}
+
+		var result sql.Result
+		result, err = tx.Exec(variantQueries.updateLocation, loc.Quantity, loc.VariantLocationID, loc.read)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+		var affected int64
+		affected, err = result.RowsAffected()
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+		if affected == 0 {
+			return failure.Conflict("update", "Variant", "stock changed meanwhile, read it again before updating its quantity")
+		}
+		_, err = tx.NamedExec(InsertMovementQuery, NewStockMovement(MovementAdjust, vari.VariantID, loc.WarehouseID, loc.Quantity-loc.read))
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+	if len(vari.Locations) > 0 {
+		_, err = tx.Exec(SyncQuantityQuery, vari.VariantID.String())
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+
+	_, err = tx.Exec(SyncStatusQuery+" WHERE variant.variant_id = ?", globalThreshold, vari.VariantID.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// HardDelete deletes a Variant. Its images and stock Locations are removed by
+// the cascading foreign keys.
+func (r *VariantRepositoryMariaDB) HardDelete(variantId uuid.UUID) (err error) {
+	result, err := r.DB.Write.Exec(variantQueries.deleteVariant, variantId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	if affected == 0 {
+		err = failure.NotFound("variant")
+	}
+	return
+}
+
+// SyncDefaultStatuses derives again the status of the Variants of Products
+// without their own limited threshold, which depends on globalThreshold.
+func (r *VariantRepositoryMariaDB) SyncDefaultStatuses(globalThreshold int) (err error) {
+	_, err = r.DB.Write.Exec(SyncStatusQuery+" WHERE product.limited_threshold IS NULL", globalThreshold)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// attachDetails fetches the images, with their renditions, and stock
+// Locations of several Variants at once.
+func (r *VariantRepositoryMariaDB) attachDetails(varis []Variant) (err error) {
+	if len(varis) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(varis))
+	for _, vari := range varis {
+		ids = append(ids, vari.VariantID.String())
+	}
+
+	query, args, err := sqlx.In(variantQueries.selectImages, ids)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	var imgs []image.Image
+	err = r.DB.Read.Select(&imgs, query, args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	err = image.AttachRenditions(r.DB.Read, imgs)
+	if err != nil {
+		return
+	}
+
+	query, args, err = sqlx.In(variantQueries.selectLocations, ids)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	var locs []Location
+	err = r.DB.Read.Select(&locs, query, args...)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	for i := range varis {
+		for _, img := range imgs {
+			if img.VariantID == varis[i].VariantID {
+				varis[i].Images = append(varis[i].Images, img)
+			}
+		}
+		varis[i].Locations = []Location{}
+		varis[i].AttachLocations(locs)
+	}
+	return
+}
@@ -1 +1,102 @@
 package variants
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/gofrs/uuid"
+)
+
+type VariantService interface {
+	GetVariantsByProductID(prodId uuid.UUID, pg pagination.Pagination) (varis []Variant, total int, err error)
+	GetVariantByID(variantId uuid.UUID) (vari Variant, err error)
+	GetVariantOwner(variantId uuid.UUID) (ownerID uuid.UUID, err error)
+	Update(variantId uuid.UUID, payload PayloadUpdateVariant, userID uuid.UUID) (vari Variant, err error)
+	SoftDelete(variantId uuid.UUID, userID uuid.UUID) (vari Variant, err error)
+	HardDelete(variantId uuid.UUID) (err error)
+	SyncDefaultStatuses() (err error)
+}
+
+type VariantServiceImpl struct {
+	VariantRepository VariantRepository
+	Config            *configs.Config
+}
+
+func ProvideVariantServiceImpl(variantRepo VariantRepository, config *configs.Config) *VariantServiceImpl {
+	s := new(VariantServiceImpl)
+	s.VariantRepository = variantRepo
+	s.Config = config
+
+	return s
+}
+
+func (s *VariantServiceImpl) GetVariantsByProductID(prodId uuid.UUID, pg pagination.Pagination) (varis []Variant, total int, err error) {
+	exists, err := s.VariantRepository.ProductExistsByID(prodId)
+	if err != nil {
+		return
+	}
+	if !exists {
+		err = failure.NotFound("product")
+		return
+	}
+
+	varis, err = s.VariantRepository.GetVariantsByProductID(prodId, pg.Limit, pg.Offset)
+	if err != nil {
+		return
+	}
+	total, err = s.VariantRepository.CountVariantsByProductID(prodId)
+	return
+}
+
+func (s *VariantServiceImpl) GetVariantByID(variantId uuid.UUID) (vari Variant, err error) {
+	vari, err = s.VariantRepository.GetVariantByID(variantId)
+	return
+}
+
+// GetVariantOwner returns the ID of the user owning the Product of a Variant.
+func (s *VariantServiceImpl) GetVariantOwner(variantId uuid.UUID) (ownerID uuid.UUID, err error) {
+	ownerID, err = s.VariantRepository.GetVariantOwner(variantId)
+	return
+}
+
+func (s *VariantServiceImpl) Update(variantId uuid.UUID, payload PayloadUpdateVariant, userID uuid.UUID) (vari Variant, err error) {
+	vari, err = s.VariantRepository.GetVariantByID(variantId)
+	if err != nil {
+		return
+	}
+	productThreshold, err := s.VariantRepository.GetLimitedThreshold(vari.ProductID)
+	if err != nil {
+		return
+	}
+	err = vari.Update(payload, userID, LimitedThreshold(productThreshold, s.Config.Variant.LimitedThreshold))
+	if err != nil {
+		return
+	}
+	err = s.VariantRepository.Update(vari, s.Config.Variant.LimitedThreshold)
+	return
+}
+
+func (s *VariantServiceImpl) SoftDelete(variantId uuid.UUID, userID uuid.UUID) (vari Variant, err error) {
+	vari, err = s.VariantRepository.GetVariantByID(variantId)
+	if err != nil {
+		return
+	}
+	err = vari.SoftDelete(userID)
+	if err != nil {
+		return
+	}
+	err = s.VariantRepository.Update(vari, s.Config.Variant.LimitedThreshold)
+	return
+}
+
+func (s *VariantServiceImpl) HardDelete(variantId uuid.UUID) (err error) {
+	err = s.VariantRepository.HardDelete(variantId)
+	return
+}
+
+// SyncDefaultStatuses derives the status of the Variants following the
+// configured limited threshold again. It is run on startup, as the threshold
+// may have changed since the statuses were derived.
+func (s *VariantServiceImpl) SyncDefaultStatuses() (err error) {
+	return s.VariantRepository.SyncDefaultStatuses(s.Config.Variant.LimitedThreshold)
+}
@@ -1,19 +1,118 @@
 package warehouse
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
 )
 
 type Warehouse struct {
-	WarehouseID   uuid.UUID
-	WarehouseName string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	DeletedAt     null.Time
-	CreatedBy     uuid.UUID
-	UpdatedBy     uuid.UUID
-	DeletedBy     uuid.UUID
+	WarehouseID   int         `db:"warehouse_id"`
+	WarehouseName string      `db:"warehouse_name" validate:"required,max=100"`
+	CreatedAt     time.Time   `db:"created_at" validate:"required"`
+	UpdatedAt     time.Time   `db:"updated_at" validate:"required"`
+	DeletedAt     null.Time   `db:"deleted_at"`
+	CreatedBy     uuid.UUID   `db:"created_by" validate:"required"`
+	UpdatedBy     uuid.UUID   `db:"updated_by" validate:"required"`
+	DeletedBy     nuuid.NUUID `db:"deleted_by"`
+}
+
+type PayloadWarehouse struct {
+	WarehouseName string `json:"warehouseName" validate:"required"`
+}
+
+// PayloadAssignStock sets the quantity of a Variant held in a warehouse.
+type PayloadAssignStock struct {
+	Quantity int `json:"quantity" validate:"min=0"`
+}
+
+// PayloadAdjustStock adds Delta, which may be negative, to the quantity of a
+// Variant held in a warehouse.
+type PayloadAdjustStock struct {
+	Delta int `json:"delta" validate:"required"`
+}
+
+// PayloadMoveStock moves Quantity units of a Variant to another warehouse.
+type PayloadMoveStock struct {
+	ToWarehouseID int `json:"toWarehouseId" validate:"required,min=1"`
+	Quantity      int `json:"quantity" validate:"required,min=1"`
+}
+
+type WarehouseResponseFormat struct {
+	WarehouseID   int         `json:"warehouseId"`
+	WarehouseName string      `json:"warehouseName"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+	DeletedAt     null.Time   `json:"deletedAt"`
+	CreatedBy     uuid.UUID   `json:"createdBy"`
+	UpdatedBy     uuid.UUID   `json:"updatedBy"`
+	DeletedBy     nuuid.NUUID `json:"deletedBy"`
+}
+
+func (wh Warehouse) NewFromPayload(payload PayloadWarehouse, userID uuid.UUID) (Warehouse, error) {
+	newWarehouse := Warehouse{
+		WarehouseName: payload.WarehouseName,
+		CreatedAt:     time.Now().UTC(),
+		CreatedBy:     userID,
+		UpdatedAt:     time.Now().UTC(),
+		UpdatedBy:     userID,
+	}
+
+	err := newWarehouse.Validate()
+	return newWarehouse, err
+}
+
+func (wh *Warehouse) ToResponseFormat() WarehouseResponseFormat {
+	resp := WarehouseResponseFormat{
+		WarehouseID:   wh.WarehouseID,
+		WarehouseName: wh.WarehouseName,
+		CreatedAt:     wh.CreatedAt,
+		UpdatedAt:     wh.UpdatedAt,
+		DeletedAt:     wh.DeletedAt,
+		CreatedBy:     wh.CreatedBy,
+		UpdatedBy:     wh.UpdatedBy,
+		DeletedBy:     wh.DeletedBy,
+	}
+	return resp
+}
+
+func (wh *Warehouse) Validate() error {
+	validator := shared.GetValidator()
+	return validator.Struct(wh)
+}
+
+func (wh Warehouse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wh.ToResponseFormat())
+}
+
+func (wh *Warehouse) IsDeleted() (deleted bool) {
+	return wh.DeletedAt.Valid && wh.DeletedBy.Valid
+}
+
+func (wh *Warehouse) SoftDelete(userID uuid.UUID) (err error) {
+	if wh.IsDeleted() {
+		return failure.Conflict("softDelete", "Warehouse", "already marked as deleted")
+	}
+
+	wh.DeletedAt = null.TimeFrom(time.Now().UTC())
+	wh.DeletedBy = nuuid.From(userID)
+	return
+}
+
+func (wh *Warehouse) Update(req PayloadWarehouse, userID uuid.UUID) (err error) {
+	if wh.IsDeleted() {
+		return failure.Conflict("update", "Warehouse", "already marked as deleted")
+	}
+
+	wh.UpdatedAt = time.Now().UTC()
+	wh.UpdatedBy = userID
+	wh.WarehouseName = req.WarehouseName
+
+	err = wh.Validate()
+	return
 }
@@ -0,0 +1,419 @@
+package warehouse
+
+import (
+	"database/sql"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	warehouseQueries = struct {
+		selectWarehouse string
+		insertWarehouse string
+		updateWarehouse string
+		deleteWarehouse string
+		lockLocations   string
+		countReserved   string
+		selectLocation  string
+		lockLocation    string
+		upsertLocation  string
+		addToLocation   string
+	}{
+		selectWarehouse: `
+			SELECT
+				warehouse_id,
+				warehouse_name,
+				created_at,
+				updated_at,
+				deleted_at,
+				created_by,
+				updated_by,
+				deleted_by
+			FROM warehouse`,
+
+		insertWarehouse: `
+			INSERT INTO warehouse (warehouse_name, created_at, updated_at, created_by, updated_by)
+			VALUES (:warehouse_name, :created_at, :updated_at, :created_by, :updated_by)`,
+
+		updateWarehouse: `
+			UPDATE warehouse
+			SET
+				warehouse_name = :warehouse_name,
+				updated_at = :updated_at,
+				updated_by = :updated_by,
+				deleted_at = :deleted_at,
+				deleted_by = :deleted_by
+			WHERE warehouse_id = :warehouse_id`,
+
+		deleteWarehouse: `DELETE FROM warehouse WHERE warehouse_id = ?`,
+
+		lockLocations: `
+			SELECT variant_id, variant_quantity
+			FROM variant_location
+			WHERE warehouse_id = ?
+			FOR UPDATE`,
+
+		countReserved: `
+			SELECT COUNT(*)
+			FROM stock_reservation
+			WHERE warehouse_id = ? AND status = 'reserved'`,
+
+		selectLocation: `
+			SELECT
+				vl.variant_location_id,
+				vl.warehouse_id,
+				w.warehouse_name,
+				vl.variant_id,
+				vl.variant_quantity
+			FROM variant_location vl
+			JOIN warehouse w ON w.warehouse_id = vl.warehouse_id`,
+
+		lockLocation: `
+			SELECT variant_quantity
+			FROM variant_location
+			WHERE warehouse_id = ? AND variant_id = ?
+			FOR UPDATE`,
+
+		upsertLocation: `
+			INSERT INTO variant_location (warehouse_id, variant_id, variant_quantity)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE variant_quantity = VALUES(variant_quantity)`,
+
+		addToLocation: `
+			INSERT INTO variant_location (warehouse_id, variant_id, variant_quantity)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE variant_quantity = variant_quantity + VALUES(variant_quantity)`,
+	}
+)
+
+type WarehouseRepository interface {
+	Create(wh Warehouse) (created Warehouse, err error)
+	GetAllWarehouses(sort string, limit, offset int) (whs []Warehouse, err error)
+	CountWarehouses() (total int, err error)
+	GetWarehouseByID(warehouseId int) (wh Warehouse, err error)
+	Update(wh Warehouse) (err error)
+	HardDelete(warehouseId int, limitedThreshold int) (err error)
+	VariantExistsByID(variantId uuid.UUID) (exists bool, err error)
+	GetLocationsByWarehouseID(warehouseId int) (locs []variants.Location, err error)
+	GetLocationsByVariantID(variantId uuid.UUID) (locs []variants.Location, err error)
+	AssignStock(warehouseId int, variantId uuid.UUID, quantity, limitedThreshold int) (err error)
+	AdjustStock(warehouseId int, variantId uuid.UUID, delta, limitedThreshold int) (err error)
+	MoveStock(fromWarehouseId, toWarehouseId int, variantId uuid.UUID, quantity int) (err error)
+}
+
+type WarehouseRepositoryMariaDB struct {
+	DB *infras.MariaDBConn
+}
+
+func ProvideWarehouseRepositoryMariaDB(db *infras.MariaDBConn) *WarehouseRepositoryMariaDB {
+	s := new(WarehouseRepositoryMariaDB)
+	s.DB = db
+	return s
+}
+
+func (r *WarehouseRepositoryMariaDB) Create(wh Warehouse) (created Warehouse, err error) {
+	stmt, err := r.DB.Write.PrepareNamed(warehouseQueries.insertWarehouse)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(wh)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	created = wh
+	created.WarehouseID = int(id)
+	return
+}
+
+func (r *WarehouseRepositoryMariaDB) GetAllWarehouses(sort string, limit, offset int) (whs []Warehouse, err error) {
+	query := warehouseQueries.selectWarehouse + " ORDER BY warehouse_id " + sort + " LIMIT ? OFFSET ?"
+	err = r.DB.Read.Select(&whs, query, limit, offset)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+func (r *WarehouseRepositoryMariaDB) CountWarehouses() (total int, err error) {
+	err = r.DB.Read.Get(&total, "SELECT COUNT(*) FROM warehouse")
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *WarehouseRepositoryMariaDB) GetWarehouseByID(warehouseId int) (wh Warehouse, err error) {
+	err = r.DB.Read.Get(&wh, warehouseQueries.selectWarehouse+" WHERE warehouse_id = ?", warehouseId)
+	if err == sql.ErrNoRows {
+		err = failure.NotFound("warehouse")
+		logger.ErrorWithStack(err)
+		return
+	}
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+func (r *WarehouseRepositoryMariaDB) Update(wh Warehouse) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		if err := r.txUpdate(tx, wh); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+// HardDelete deletes a Warehouse along with its stock Locations. The stock
+// removed is recorded in the ledger and the quantity and status of its
+// Variants derived again, like in AdjustStock. Warehouses holding pending
+// Reservations are kept, as their stock could not be put back. Locking the
+// Locations first makes reservations racing the delete wait for it, then find
+// no stock to take.
+func (r *WarehouseRepositoryMariaDB) HardDelete(warehouseId int, limitedThreshold int) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		var locs []variants.Location
+		if err := tx.Select(&locs, warehouseQueries.lockLocations, warehouseId); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		var reserved int
+		if err := tx.Get(&reserved, warehouseQueries.countReserved, warehouseId); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		if reserved > 0 {
+			c <- failure.Conflict("hardDelete", "Warehouse", "has pending reservations")
+			return
+		}
+
+		if _, err := tx.Exec(warehouseQueries.deleteWarehouse, warehouseId); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		for _, loc := range locs {
+			if err := r.txRecord(tx, variants.NewStockMovement(variants.MovementAdjust, loc.VariantID, warehouseId, -loc.Quantity)); err != nil {
+				c <- err
+				return
+			}
+			if err := r.txSyncVariantTotal(tx, loc.VariantID, limitedThreshold); err != nil {
+				c <- err
+				return
+			}
+		}
+		c <- nil
+	})
+}
+
+func (r *WarehouseRepositoryMariaDB) VariantExistsByID(variantId uuid.UUID) (exists bool, err error) {
+	err = r.DB.Read.Get(&exists, "SELECT COUNT(variant_id) FROM variant WHERE variant_id = ?", variantId.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *WarehouseRepositoryMariaDB) GetLocationsByWarehouseID(warehouseId int) (locs []variants.Location, err error) {
+	err = r.DB.Read.Select(&locs, warehouseQueries.selectLocation+" WHERE vl.warehouse_id = ? ORDER BY vl.variant_id", warehouseId)
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+func (r *WarehouseRepositoryMariaDB) GetLocationsByVariantID(variantId uuid.UUID) (locs []variants.Location, err error) {
+	err = r.DB.Read.Select(&locs, warehouseQueries.selectLocation+" WHERE vl.variant_id = ? ORDER BY vl.warehouse_id", variantId.String())
+	if err != nil {
+		err = failure.InternalError(err)
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+// AssignStock sets the quantity of a Variant in a warehouse. The status of the
+// Variant is derived from its new total with limitedThreshold, the global
+// threshold, unless its Product has its own.
+func (r *WarehouseRepositoryMariaDB) AssignStock(warehouseId int, variantId uuid.UUID, quantity, limitedThreshold int) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		current, err := r.txLockQuantity(tx, warehouseId, variantId)
+		if err != nil {
+			c <- err
+			return
+		}
+		if _, err := tx.Exec(warehouseQueries.upsertLocation, warehouseId, variantId.String(), quantity); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		if err := r.txRecord(tx, variants.NewStockMovement(variants.MovementAssign, variantId, warehouseId, quantity-current)); err != nil {
+			c <- err
+			return
+		}
+		if err := r.txSyncVariantTotal(tx, variantId, limitedThreshold); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+// AdjustStock adds delta to the quantity of a Variant in a warehouse. The
+// status of the Variant is derived like in AssignStock.
+func (r *WarehouseRepositoryMariaDB) AdjustStock(warehouseId int, variantId uuid.UUID, delta, limitedThreshold int) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		current, err := r.txLockQuantity(tx, warehouseId, variantId)
+		if err != nil {
+			c <- err
+			return
+		}
+		if current+delta < 0 {
+			c <- failure.Conflict("adjust", "stock", "insufficient quantity in warehouse")
+			return
+		}
+		if _, err := tx.Exec(warehouseQueries.upsertLocation, warehouseId, variantId.String(), current+delta); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		if err := r.txRecord(tx, variants.NewStockMovement(variants.MovementAdjust, variantId, warehouseId, delta)); err != nil {
+			c <- err
+			return
+		}
+		if err := r.txSyncVariantTotal(tx, variantId, limitedThreshold); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+func (r *WarehouseRepositoryMariaDB) MoveStock(fromWarehouseId, toWarehouseId int, variantId uuid.UUID, quantity int) (err error) {
+	return r.DB.WithTransaction(func(tx *sqlx.Tx, c chan error) {
+		// Both rows are locked in warehouse order, so that opposite moves
+		// between the same warehouses wait for each other instead of
+		// deadlocking.
+		order := []int{fromWarehouseId, toWarehouseId}
+		if toWarehouseId < fromWarehouseId {
+			order = []int{toWarehouseId, fromWarehouseId}
+		}
+		var current int
+		for _, warehouseId := range order {
+			locked, err := r.txLockQuantity(tx, warehouseId, variantId)
+			if err != nil {
+				c <- err
+				return
+			}
+			if warehouseId == fromWarehouseId {
+				current = locked
+			}
+		}
+		if current < quantity {
+			c <- failure.Conflict("move", "stock", "insufficient quantity in source warehouse")
+			return
+		}
+		if _, err := tx.Exec(warehouseQueries.upsertLocation, fromWarehouseId, variantId.String(), current-quantity); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		if _, err := tx.Exec(warehouseQueries.addToLocation, toWarehouseId, variantId.String(), quantity); err != nil {
+			logger.ErrorWithStack(err)
+			c <- err
+			return
+		}
+		if err := r.txRecord(tx, variants.NewStockMovement(variants.MovementMove, variantId, fromWarehouseId, -quantity)); err != nil {
+			c <- err
+			return
+		}
+		if err := r.txRecord(tx, variants.NewStockMovement(variants.MovementMove, variantId, toWarehouseId, quantity)); err != nil {
+			c <- err
+			return
+		}
+		c <- nil
+	})
+}
+
+// txLockQuantity locks the variant_location row for the duration of the
+// transaction and returns its quantity, or zero if there is no such row yet.
+func (r *WarehouseRepositoryMariaDB) txLockQuantity(tx *sqlx.Tx, warehouseId int, variantId uuid.UUID) (quantity int, err error) {
+	err = tx.Get(&quantity, warehouseQueries.lockLocation, warehouseId, variantId.String())
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// txSyncVariantTotal keeps variant.quantity equal to the sum of its locations
+// and variant.status derived from it.
+func (r *WarehouseRepositoryMariaDB) txSyncVariantTotal(tx *sqlx.Tx, variantId uuid.UUID, limitedThreshold int) (err error) {
+	_, err = tx.Exec(variants.SyncQuantityQuery, variantId.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	_, err = tx.Exec(variants.SyncStatusQuery+" WHERE variant.variant_id = ?", limitedThreshold, variantId.String())
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// txRecord appends a stock change to the stock_movement ledger. Changes of
+// zero units are not recorded.
+func (r *WarehouseRepositoryMariaDB) txRecord(tx *sqlx.Tx, movement variants.StockMovement) (err error) {
+	if movement.Quantity == 0 {
+		return
+	}
+	_, err = tx.NamedExec(variants.InsertMovementQuery, movement)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+func (r *WarehouseRepositoryMariaDB) txUpdate(tx *sqlx.Tx, wh Warehouse) (err error) {
+	stmt, err := tx.PrepareNamed(warehouseQueries.updateWarehouse)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(wh)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package warehouse_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/warehouse"
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveStockLocksInWarehouseOrder(t *testing.T) {
+	lockLocation := regexp.QuoteMeta("WHERE warehouse_id = ? AND variant_id = ?") + `\s+FOR UPDATE`
+	variantId, _ := uuid.NewV4()
+
+	for _, move := range [][2]int{{1, 2}, {2, 1}} {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := sqlx.NewDb(db, "mysql")
+		repo := warehouse.ProvideWarehouseRepositoryMariaDB(&infras.MariaDBConn{Read: conn, Write: conn})
+
+		from, to := move[0], move[1]
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockLocation).WithArgs(1, variantId.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"variant_quantity"}).AddRow(5))
+		mock.ExpectQuery(lockLocation).WithArgs(2, variantId.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"variant_quantity"}).AddRow(5))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO variant_location")).WithArgs(from, variantId.String(), 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO variant_location")).WithArgs(to, variantId.String(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO stock_movement")).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO stock_movement")).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.MoveStock(from, to, variantId, 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	}
+}
@@ -0,0 +1,171 @@
+package warehouse
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/gofrs/uuid"
+)
+
+type WarehouseService interface {
+	Create(payload PayloadWarehouse, userID uuid.UUID) (wh Warehouse, err error)
+	GetAllWarehouses(pg pagination.Pagination) (whs []Warehouse, total int, err error)
+	GetWarehouseByID(warehouseId int) (wh Warehouse, err error)
+	Update(warehouseId int, payload PayloadWarehouse, userID uuid.UUID) (wh Warehouse, err error)
+	SoftDelete(warehouseId int, userID uuid.UUID) (wh Warehouse, err error)
+	HardDelete(warehouseId int) (err error)
+	GetStock(warehouseId int) (locs []variants.Location, err error)
+	AssignStock(warehouseId int, variantId uuid.UUID, payload PayloadAssignStock) (locs []variants.Location, err error)
+	AdjustStock(warehouseId int, variantId uuid.UUID, payload PayloadAdjustStock) (locs []variants.Location, err error)
+	MoveStock(warehouseId int, variantId uuid.UUID, payload PayloadMoveStock) (locs []variants.Location, err error)
+}
+
+type WarehouseServiceImpl struct {
+	WarehouseRepository WarehouseRepository
+	Config              *configs.Config
+}
+
+func ProvideWarehouseServiceImpl(warehouseRepo WarehouseRepository, config *configs.Config) *WarehouseServiceImpl {
+	s := new(WarehouseServiceImpl)
+	s.WarehouseRepository = warehouseRepo
+	s.Config = config
+
+	return s
+}
+
+func (s *WarehouseServiceImpl) Create(payload PayloadWarehouse, userID uuid.UUID) (wh Warehouse, err error) {
+	wh, err = wh.NewFromPayload(payload, userID)
+	if err != nil {
+		return
+	}
+	wh, err = s.WarehouseRepository.Create(wh)
+	return
+}
+
+func (s *WarehouseServiceImpl) GetAllWarehouses(pg pagination.Pagination) (whs []Warehouse, total int, err error) {
+	whs, err = s.WarehouseRepository.GetAllWarehouses(pg.Sort, pg.Limit, pg.Offset)
+	if err != nil {
+		return
+	}
+	total, err = s.WarehouseRepository.CountWarehouses()
+	return
+}
+
+func (s *WarehouseServiceImpl) GetWarehouseByID(warehouseId int) (wh Warehouse, err error) {
+	wh, err = s.WarehouseRepository.GetWarehouseByID(warehouseId)
+	return
+}
+
+func (s *WarehouseServiceImpl) Update(warehouseId int, payload PayloadWarehouse, userID uuid.UUID) (wh Warehouse, err error) {
+	wh, err = s.WarehouseRepository.GetWarehouseByID(warehouseId)
+	if err != nil {
+		return
+	}
+	err = wh.Update(payload, userID)
+	if err != nil {
+		return
+	}
+	err = s.WarehouseRepository.Update(wh)
+	return
+}
+
+func (s *WarehouseServiceImpl) SoftDelete(warehouseId int, userID uuid.UUID) (wh Warehouse, err error) {
+	wh, err = s.WarehouseRepository.GetWarehouseByID(warehouseId)
+	if err != nil {
+		return
+	}
+	err = wh.SoftDelete(userID)
+	if err != nil {
+		return
+	}
+	err = s.WarehouseRepository.Update(wh)
+	return
+}
+
+func (s *WarehouseServiceImpl) HardDelete(warehouseId int) (err error) {
+	_, err = s.WarehouseRepository.GetWarehouseByID(warehouseId)
+	if err != nil {
+		return
+	}
+	err = s.WarehouseRepository.HardDelete(warehouseId, s.Config.Variant.LimitedThreshold)
+	return
+}
+
+func (s *WarehouseServiceImpl) GetStock(warehouseId int) (locs []variants.Location, err error) {
+	_, err = s.WarehouseRepository.GetWarehouseByID(warehouseId)
+	if err != nil {
+		return
+	}
+	locs, err = s.WarehouseRepository.GetLocationsByWarehouseID(warehouseId)
+	return
+}
+
+func (s *WarehouseServiceImpl) AssignStock(warehouseId int, variantId uuid.UUID, payload PayloadAssignStock) (locs []variants.Location, err error) {
+	err = s.checkStockTarget(warehouseId, variantId)
+	if err != nil {
+		return
+	}
+	err = s.WarehouseRepository.AssignStock(warehouseId, variantId, payload.Quantity, s.Config.Variant.LimitedThreshold)
+	if err != nil {
+		return
+	}
+	locs, err = s.WarehouseRepository.GetLocationsByVariantID(variantId)
+	return
+}
+
+func (s *WarehouseServiceImpl) AdjustStock(warehouseId int, variantId uuid.UUID, payload PayloadAdjustStock) (locs []variants.Location, err error) {
+	err = s.checkStockTarget(warehouseId, variantId)
+	if err != nil {
+		return
+	}
+	err = s.WarehouseRepository.AdjustStock(warehouseId, variantId, payload.Delta, s.Config.Variant.LimitedThreshold)
+	if err != nil {
+		return
+	}
+	locs, err = s.WarehouseRepository.GetLocationsByVariantID(variantId)
+	return
+}
+
+func (s *WarehouseServiceImpl) MoveStock(warehouseId int, variantId uuid.UUID, payload PayloadMoveStock) (locs []variants.Location, err error) {
+	if warehouseId == payload.ToWarehouseID {
+		err = failure.BadRequestFromString("source and target warehouse must differ")
+		return
+	}
+	// stock may still be moved out of a soft deleted warehouse
+	_, err = s.WarehouseRepository.GetWarehouseByID(warehouseId)
+	if err != nil {
+		return
+	}
+	err = s.checkStockTarget(payload.ToWarehouseID, variantId)
+	if err != nil {
+		return
+	}
+	err = s.WarehouseRepository.MoveStock(warehouseId, payload.ToWarehouseID, variantId, payload.Quantity)
+	if err != nil {
+		return
+	}
+	locs, err = s.WarehouseRepository.GetLocationsByVariantID(variantId)
+	return
+}
+
+// checkStockTarget makes sure stock only ever lands in an active warehouse and
+// belongs to an existing variant.
+func (s *WarehouseServiceImpl) checkStockTarget(warehouseId int, variantId uuid.UUID) (err error) {
+	wh, err := s.WarehouseRepository.GetWarehouseByID(warehouseId)
+	if err != nil {
+		return
+	}
+	if wh.IsDeleted() {
+		return failure.Conflict("stock", "Warehouse", "warehouse is marked as deleted")
+	}
+
+	exists, err := s.WarehouseRepository.VariantExistsByID(variantId)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return failure.NotFound("variant")
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/evermos/boilerplate-go/shared/blob"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+)
+
+// BlobHandler serves the objects of the blob store, such as uploaded images,
+// at the URLs the store hands out when its base URL points at this service.
+type BlobHandler struct {
+	Store blob.Store
+}
+
+func ProvideBlobHandler(store blob.Store) BlobHandler {
+	return BlobHandler{
+		Store: store,
+	}
+}
+
+func (h *BlobHandler) Router(r chi.Router) {
+	r.Get("/blobs/*", h.GetBlob)
+}
+
+// GetBlob streams the object stored under the key following /blobs/.
+func (h *BlobHandler) GetBlob(w http.ResponseWriter, r *http.Request) {
+	key, err := blob.CleanKey(chi.URLParam(r, "*"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	body, err := h.Store.Get(key)
+	if err == blob.ErrNotFound {
+		response.WithError(w, failure.NotFound("blob"))
+		return
+	}
+	if err != nil {
+		response.WithError(w, failure.InternalError(err))
+		return
+	}
+	defer body.Close()
+
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	// Keys embed the ID of their object, so they never change content.
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, body); err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
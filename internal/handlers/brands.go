@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/internal/domain/brand"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+)
+
+type BrandHandler struct {
+	BrandService    brand.BrandService
+	AuthMiddleware  *middleware.Authentication
+	AuthzMiddleware *middleware.Authorization
+}
+
+func ProvideBrandHandler(brandService brand.BrandService, authMiddleware *middleware.Authentication, authzMiddleware *middleware.Authorization) BrandHandler {
+	return BrandHandler{
+		BrandService:    brandService,
+		AuthMiddleware:  authMiddleware,
+		AuthzMiddleware: authzMiddleware,
+	}
+}
+
+func (h *BrandHandler) Router(r chi.Router) {
+	r.Route("/brands", func(r chi.Router) {
+		r.Get("/", h.GetAllBrands)
+		r.Get("/{id}", h.GetBrandByID)
+		r.Get("/{id}/products", h.GetProductsByBrandID)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.AuthMiddleware.Password)
+			r.Use(h.AuthMiddleware.RequireScopes("brands:write"))
+			r.Use(h.AuthzMiddleware.RequireRole(user.Admin))
+			r.Post("/", h.CreateBrand)
+			r.Put("/{id}", h.UpdateBrand)
+			r.Delete("/soft/{id}", h.SoftDelete)
+			r.Delete("/hard/{id}", h.HardDelete)
+		})
+	})
+}
+
+// CreateBrand creates a new Brand.
+// @Summary Create a new Brand.
+// @Description This endpoint creates a new Brand.
+// @Tags brands
+// @Security EVMOauthToken
+// @Param brand body brand.PayloadBrand true "The Brand to be created."
+// @Produce json
+// @Success 201 {object} response.Base{data=brand.BrandResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/brands [post]
+func (h *BrandHandler) CreateBrand(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat brand.PayloadBrand
+	err := decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	b, err := h.BrandService.Create(requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, b)
+}
+
+// GetAllBrands lists Brands ordered by name.
+// @Summary List Brands.
+// @Description This endpoint lists the Brands not marked as deleted, ordered by name.
+// @Tags brands
+// @Param page query int false "Page number, starting at 1."
+// @Param limit query int false "Page size, 20 by default and 100 at most."
+// @Param sort query string false "Sort direction, asc or desc."
+// @Produce json
+// @Success 200 {object} response.Paginated{data=[]brand.BrandResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/brands [get]
+func (h *BrandHandler) GetAllBrands(w http.ResponseWriter, r *http.Request) {
+	pg, err := parsePageQuery(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	brands, total, err := h.BrandService.GetAllBrands(pg)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	respondPage(w, r, pg, total, brands)
+}
+
+// GetBrandByID resolves a Brand by its ID.
+// @Summary Resolve Brand by ID.
+// @Description This endpoint resolves a Brand by its ID.
+// @Tags brands
+// @Param id path string true "The Brand's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=brand.BrandResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/brands/{id} [get]
+func (h *BrandHandler) GetBrandByID(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.FromString(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	b, err := h.BrandService.GetBrandByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, b)
+}
+
+// GetProductsByBrandID lists the Products of a Brand.
+// @Summary List Products for a Brand.
+// @Description This endpoint lists the Products of a Brand not marked as deleted, ordered by creation time.
+// @Tags brands
+// @Param id path string true "The Brand's identifier."
+// @Param page query int false "Page number, starting at 1."
+// @Param limit query int false "Page size, 20 by default and 100 at most."
+// @Param sort query string false "Sort direction, asc or desc."
+// @Produce json
+// @Success 200 {object} response.Paginated{data=[]products.ProductResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/brands/{id}/products [get]
+func (h *BrandHandler) GetProductsByBrandID(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.FromString(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	pg, err := parsePageQuery(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	prods, total, err := h.BrandService.GetProductsByBrandID(id, pg)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	respondPage(w, r, pg, total, prods)
+}
+
+// UpdateBrand updates a Brand.
+// @Summary Update a Brand.
+// @Description This endpoint updates an existing Brand.
+// @Tags brands
+// @Security EVMOauthToken
+// @Param id path string true "The Brand's identifier."
+// @Param brand body brand.PayloadBrand true "The Brand to be updated."
+// @Produce json
+// @Success 200 {object} response.Base{data=brand.BrandResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/brands/{id} [put]
+func (h *BrandHandler) UpdateBrand(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.FromString(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat brand.PayloadBrand
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	b, err := h.BrandService.Update(id, requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, b)
+}
+
+// SoftDelete marks a Brand as deleted.
+// @Summary Mark a Brand as deleted.
+// @Description This endpoint marks an existing Brand as deleted by setting its
+// @Description "deletedAt" and "deletedBy" properties.
+// @Tags brands
+// @Security EVMOauthToken
+// @Param id path string true "The Brand's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=brand.BrandResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/brands/soft/{id} [delete]
+func (h *BrandHandler) SoftDelete(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.FromString(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	b, err := h.BrandService.SoftDelete(id, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, b)
+}
+
+// HardDelete permanently deletes a Brand along with its Products.
+// @Summary Permanently delete a Brand.
+// @Description This endpoint deletes a Brand. Its Products are removed by the
+// @Description cascading foreign key on product.brand_id.
+// @Tags brands
+// @Security EVMOauthToken
+// @Param id path string true "The Brand's identifier."
+// @Produce json
+// @Success 204
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/brands/hard/{id} [delete]
+func (h *BrandHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.FromString(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.BrandService.HardDelete(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.NoContent(w)
+}
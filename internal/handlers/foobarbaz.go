@@ -73,7 +73,11 @@ func (h *FooBarBazHandler) CreateFoo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
 
 	foo, err := h.FooService.Create(requestFormat, userID)
 	if err != nil {
@@ -137,7 +141,11 @@ func (h *FooBarBazHandler) SoftDeleteFoo(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
 
 	foo, err := h.FooService.SoftDelete(id, userID)
 	if err != nil {
@@ -183,7 +191,11 @@ func (h *FooBarBazHandler) UpdateFoo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
 
 	foo, err := h.FooService.Update(id, requestFormat, userID)
 	if err != nil {
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+)
+
+// InventoryHandler lets clients, such as an order service, reserve stock and
+// then commit or release it. Clients only see their own reservations.
+type InventoryHandler struct {
+	InventoryService variants.InventoryService
+	AuthMiddleware   *middleware.Authentication
+}
+
+func ProvideInventoryHandler(inventoryService variants.InventoryService, authMiddleware *middleware.Authentication) InventoryHandler {
+	return InventoryHandler{
+		InventoryService: inventoryService,
+		AuthMiddleware:   authMiddleware,
+	}
+}
+
+func (h *InventoryHandler) Router(r chi.Router) {
+	r.Route("/reservations", func(r chi.Router) {
+		r.Use(h.AuthMiddleware.ClientCredential)
+		r.Use(h.AuthMiddleware.RequireScopes("inventory:write"))
+		r.Post("/", h.Reserve)
+		r.Get("/{reservationId}", h.GetReservationByID)
+		r.Post("/{reservationId}/commit", h.Commit)
+		r.Post("/{reservationId}/release", h.Release)
+	})
+}
+
+// requestClientID returns the client of the access token of the request.
+func requestClientID(r *http.Request) string {
+	token, _ := middleware.AccessTokenFromContext(r.Context())
+	return token.ClientID
+}
+
+// Reserve takes stock of a Variant out of a warehouse.
+// @Summary Reserve stock of a Variant.
+// @Description This endpoint takes stock of a Variant out of a warehouse until the
+// @Description reservation is committed or released. Without a warehouseId the stock is
+// @Description taken from the warehouse holding the most. Reservations not settled within
+// @Description INVENTORY.RESERVATION_TTL_SECONDS expire and their stock is put back.
+// @Tags inventory
+// @Security EVMOauthToken
+// @Param reservation body variants.PayloadReservation true "The stock to be reserved."
+// @Produce json
+// @Success 201 {object} response.Base{data=variants.ReservationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/reservations [post]
+func (h *InventoryHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat variants.PayloadReservation
+	err := decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	res, err := h.InventoryService.Reserve(requestFormat, requestClientID(r))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusCreated, res)
+}
+
+// GetReservationByID resolves a Reservation by its ID.
+// @Summary Resolve Reservation by ID.
+// @Description This endpoint resolves a Reservation of the calling client by its ID.
+// @Tags inventory
+// @Security EVMOauthToken
+// @Param reservationId path string true "The Reservation's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=variants.ReservationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/reservations/{reservationId} [get]
+func (h *InventoryHandler) GetReservationByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "reservationId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	res, err := h.InventoryService.GetReservationByID(id, requestClientID(r))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, res)
+}
+
+// Commit settles a Reservation once its stock is sold.
+// @Summary Commit a Reservation.
+// @Description This endpoint settles a pending Reservation once its stock is sold. Expired
+// @Description Reservations cannot be committed.
+// @Tags inventory
+// @Security EVMOauthToken
+// @Param reservationId path string true "The Reservation's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=variants.ReservationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/reservations/{reservationId}/commit [post]
+func (h *InventoryHandler) Commit(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "reservationId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	res, err := h.InventoryService.Commit(id, requestClientID(r))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, res)
+}
+
+// Release puts the stock of a Reservation back.
+// @Summary Release a Reservation.
+// @Description This endpoint cancels a pending Reservation and puts its stock back into
+// @Description its warehouse.
+// @Tags inventory
+// @Security EVMOauthToken
+// @Param reservationId path string true "The Reservation's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=variants.ReservationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/reservations/{reservationId}/release [post]
+func (h *InventoryHandler) Release(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "reservationId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	res, err := h.InventoryService.Release(id, requestClientID(r))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, res)
+}
@@ -61,11 +61,28 @@ func (h *MaterialsHandler) CreateMaterial(w http.ResponseWriter, r *http.Request
 	response.WithJSON(w, http.StatusCreated, mat)
 }
 
+// GetAllMaterial lists Materials.
+// @Summary List Materials.
+// @Description This endpoint lists Materials ordered by creation time.
+// @Tags materials/material
+// @Param page query int false "Page number, starting at 1."
+// @Param limit query int false "Page size, 20 by default and 100 at most."
+// @Produce json
+// @Success 200 {object} response.Paginated{data=[]materials.MaterialResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/materials [get]
 func (h *MaterialsHandler) GetAllMaterial(w http.ResponseWriter, r *http.Request) {
-	mats, err := h.MaterialService.GetAll()
+	pg, err := parsePageQuery(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	mats, total, err := h.MaterialService.GetAll(pg)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
-	response.WithJSON(w, http.StatusOK, mats)
+	respondPage(w, r, pg, total, mats)
 }
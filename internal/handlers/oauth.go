@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+)
+
+// OauthHandler exposes the grants of shared/oauth over HTTP. Its responses
+// follow RFC 6749 instead of the response.Base envelope.
+type OauthHandler struct {
+	DB              *infras.MariaDBConn
+	OauthConfig     *oauth.Config
+	AuthMiddleware  *middleware.Authentication
+	AuthzMiddleware *middleware.Authorization
+}
+
+func ProvideOauthHandler(db *infras.MariaDBConn, oauthConfig *oauth.Config, authMiddleware *middleware.Authentication, authzMiddleware *middleware.Authorization) OauthHandler {
+	return OauthHandler{
+		DB:              db,
+		OauthConfig:     oauthConfig,
+		AuthMiddleware:  authMiddleware,
+		AuthzMiddleware: authzMiddleware,
+	}
+}
+
+func (h *OauthHandler) Router(r chi.Router) {
+	r.Get("/.well-known/jwks.json", h.GetJWKS)
+
+	r.Route("/oauth", func(r chi.Router) {
+		r.Post("/token", h.CreateToken)
+		r.Post("/revoke", h.RevokeToken)
+		r.Post("/introspect", h.IntrospectToken)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.AuthMiddleware.Password)
+			r.Use(h.AuthMiddleware.RequireScopes("clients:write"))
+			r.Use(h.AuthzMiddleware.RequireRole(user.Admin))
+			r.Post("/clients", h.CreateClient)
+			r.Post("/clients/{clientId}/secret", h.RotateClientSecret)
+		})
+	})
+}
+
+func (h *OauthHandler) token() *oauth.Token {
+	return oauth.New(h.DB.Write, *h.OauthConfig)
+}
+
+// CreateToken issues an access token.
+// @Summary Issue an access token.
+// @Description This endpoint implements the token endpoint of RFC 6749 for the
+// @Description client_credentials, password and refresh_token grants. Password grants
+// @Description also return a refresh token, which is rotated on use. The parameters may be sent
+// @Description form encoded or as JSON, and the client may authenticate with HTTP Basic.
+// @Tags oauth
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param credential body oauth.Credential true "The token request."
+// @Success 200 {object} oauth.TokenResponse
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Failure 500 {object} oauth.Error
+// @Router /oauth/token [post]
+func (h *OauthHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	credential, err := parseCredential(r)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+	if credential.GrantType == "" {
+		respondOauthError(w, oauth.NewError(oauth.ErrorCodeInvalidRequest, oauth.ErrorMissingGrantType))
+		return
+	}
+
+	token, err := h.clientToken(credential)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	tokenResponse, err := token.Create(credential)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	respondOauth(w, http.StatusOK, tokenResponse)
+}
+
+// RevokeToken revokes an access or refresh token.
+// @Summary Revoke an access or refresh token.
+// @Description This endpoint implements RFC 7009. Clients may only revoke their own
+// @Description tokens. Unknown tokens are ignored and still answered with 200.
+// @Tags oauth
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param credential body oauth.Credential true "The client credentials, token and optional token_type_hint."
+// @Success 200
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Failure 500 {object} oauth.Error
+// @Router /oauth/revoke [post]
+func (h *OauthHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	credential, err := parseCredential(r)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	token, err := h.clientToken(credential)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	err = token.Revoke(credential)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	respondOauth(w, http.StatusOK, struct{}{})
+}
+
+// IntrospectToken describes an access or refresh token.
+// @Summary Introspect an access or refresh token.
+// @Description This endpoint implements RFC 7662 so that other services can validate
+// @Description tokens without reading the token tables. Unknown, revoked and expired
+// @Description tokens are reported as {"active": false}.
+// @Tags oauth
+// @Accept x-www-form-urlencoded,json
+// @Produce json
+// @Param credential body oauth.Credential true "The client credentials, token and optional token_type_hint."
+// @Success 200 {object} oauth.IntrospectionResponse
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Failure 500 {object} oauth.Error
+// @Router /oauth/introspect [post]
+func (h *OauthHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	credential, err := parseCredential(r)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	token, err := h.clientToken(credential)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	introspection, err := token.Introspect(credential)
+	if err != nil {
+		respondOauthError(w, err)
+		return
+	}
+
+	respondOauth(w, http.StatusOK, introspection)
+}
+
+// GetJWKS publishes the keys JWT access tokens are signed with.
+// @Summary Get the JSON Web Key Set.
+// @Description This endpoint lists the public keys of the JWT access tokens, so that
+// @Description other services can validate them without calling introspection. The
+// @Description set is empty when only opaque tokens are issued.
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} oauth.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (h *OauthHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	body, _ := json.Marshal(h.token().JWKS())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(body)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+// CreateClient registers a new OAuth client.
+// @Summary Register a new OAuth client.
+// @Description This endpoint registers a client and generates its secret. The secret
+// @Description is only stored as a hash, so the response is the only place it is shown.
+// @Tags oauth
+// @Security EVMOauthToken
+// @Param client body oauth.ClientRegistration true "The client to be registered."
+// @Produce json
+// @Success 201 {object} response.Base{data=oauth.ClientCredentialResponse}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /oauth/clients [post]
+func (h *OauthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat oauth.ClientRegistration
+	err := decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	credential, err := h.token().CreateClient(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, credential)
+}
+
+// RotateClientSecret replaces the secret of an OAuth client.
+// @Summary Rotate the secret of an OAuth client.
+// @Description This endpoint generates a new secret for a client. The previous secret
+// @Description stops working immediately.
+// @Tags oauth
+// @Security EVMOauthToken
+// @Param clientId path string true "The client's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=oauth.ClientCredentialResponse}
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /oauth/clients/{clientId}/secret [post]
+func (h *OauthHandler) RotateClientSecret(w http.ResponseWriter, r *http.Request) {
+	clientID := chi.URLParam(r, "clientId")
+
+	credential, err := h.token().RotateClientSecret(clientID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, credential)
+}
+
+// clientToken returns the oauth.Token to serve a request from the client of
+// credential, provided the client is allowed by the configured client scope.
+func (h *OauthHandler) clientToken(credential oauth.Credential) (token *oauth.Token, err error) {
+	token = h.token()
+	if !token.ClientScopeAllowed(credential.ClientID) {
+		err = oauth.NewError(oauth.ErrorCodeInvalidClient, oauth.ErrorClientScopeNotAllowed)
+	}
+	return
+}
+
+// parseCredential reads the request parameters of the oauth endpoints from a
+// JSON or form encoded body. Client credentials sent with HTTP Basic take precedence over
+// the ones in the body.
+func parseCredential(r *http.Request) (credential oauth.Credential, err error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		err = json.NewDecoder(r.Body).Decode(&credential)
+		if err != nil {
+			err = oauth.NewError(oauth.ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+	} else {
+		err = r.ParseForm()
+		if err != nil {
+			err = oauth.NewError(oauth.ErrorCodeInvalidRequest, err.Error())
+			return
+		}
+		credential = oauth.Credential{
+			GrantType:    oauth.GrantType(r.PostForm.Get("grant_type")),
+			ClientID:     r.PostForm.Get("client_id"),
+			ClientSecret: r.PostForm.Get("client_secret"),
+			Username:     r.PostForm.Get("username"),
+			Password:     r.PostForm.Get("password"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+			Scope:        r.PostForm.Get("scope"),
+
+			Token:         r.PostForm.Get("token"),
+			TokenTypeHint: oauth.TokenTypeHint(r.PostForm.Get("token_type_hint")),
+		}
+	}
+
+	if clientID, clientSecret, ok := r.BasicAuth(); ok {
+		credential.ClientID = clientID
+		credential.ClientSecret = clientSecret
+	}
+
+	if credential.ClientID == "" {
+		err = oauth.NewError(oauth.ErrorCodeInvalidClient, oauth.ErrorMissingClientCredential)
+	}
+	return
+}
+
+// respondOauthError maps err to an RFC 6749 error response. Errors not coming
+// from shared/oauth are reported as server_error.
+func respondOauthError(w http.ResponseWriter, err error) {
+	oauthErr, ok := err.(*oauth.Error)
+	if !ok {
+		logger.ErrorWithStack(err)
+		oauthErr = oauth.NewError(oauth.ErrorCodeServerError, "")
+	}
+
+	code := http.StatusBadRequest
+	switch oauthErr.Code {
+	case oauth.ErrorCodeInvalidClient:
+		code = http.StatusUnauthorized
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	case oauth.ErrorCodeServerError:
+		code = http.StatusInternalServerError
+	}
+
+	respondOauth(w, code, oauthErr)
+}
+
+func respondOauth(w http.ResponseWriter, code int, payload interface{}) {
+	body, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(code)
+	_, err := w.Write(body)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+)
+
+const (
+	defaultPage  = 1
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// parsePageQuery reads the page, limit and sort query parameters shared by
+// the list endpoints. Missing page and limit parameters fall back to the
+// first page of defaultLimit items, and pages hold maxLimit items at most.
+func parsePageQuery(r *http.Request) (pg pagination.Pagination, err error) {
+	page, err := parseIntQuery(r, "page", defaultPage)
+	if err != nil {
+		return
+	}
+
+	limit, err := parseIntQuery(r, "limit", defaultLimit)
+	if err != nil {
+		return
+	}
+
+	if page < 1 || limit < 1 {
+		err = errors.New("page and limit must be positive")
+		return
+	}
+	if limit > maxLimit {
+		err = fmt.Errorf("limit must be %d at most", maxLimit)
+		return
+	}
+
+	sort := pagination.GetSortDirection(pagination.ParseQueryParams(r, "sort"))
+	pg = pagination.NewPaginationQuery(page, limit, sort)
+	return
+}
+
+func parseIntQuery(r *http.Request, key string, def int) (int, error) {
+	value := pagination.ParseQueryParams(r, key)
+	if value == "" {
+		return def, nil
+	}
+	return pagination.ConvertToInt(value)
+}
+
+// respondPage sends a page of an offset paginated list of totalItems items.
+func respondPage(w http.ResponseWriter, r *http.Request, pg pagination.Pagination, totalItems int, items interface{}) {
+	meta := pagination.NewMeta(pg.Page, pg.Limit, totalItems)
+	response.WithPage(w, http.StatusOK, items, meta, pagination.NewOffsetLinks(r, meta))
+}
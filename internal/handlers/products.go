@@ -2,40 +2,103 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/evermos/boilerplate-go/internal/domain/products"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
 	"github.com/evermos/boilerplate-go/internal/domain/variants"
 	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
 	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 	"github.com/go-chi/chi"
 	"github.com/gofrs/uuid"
 )
 
 type ProductHandler struct {
-	ProductService products.ProductService
+	ProductService  products.ProductService
+	VariantService  variants.VariantService
+	Cursors         *pagination.CursorCodec
+	AuthMiddleware  *middleware.Authentication
+	AuthzMiddleware *middleware.Authorization
 }
 
-func ProvideProductHandler(Productervice products.ProductService) ProductHandler {
+func ProvideProductHandler(Productervice products.ProductService, variantService variants.VariantService, cursors *pagination.CursorCodec, authMiddleware *middleware.Authentication, authzMiddleware *middleware.Authorization) ProductHandler {
 	return ProductHandler{
-		ProductService: Productervice,
+		ProductService:  Productervice,
+		VariantService:  variantService,
+		Cursors:         cursors,
+		AuthMiddleware:  authMiddleware,
+		AuthzMiddleware: authzMiddleware,
 	}
 }
 
 func (h *ProductHandler) Router(r chi.Router) {
 	r.Route("/products", func(r chi.Router) {
-		r.Get("/", h.GetAllProducts)
-		r.Post("/", h.CreateProduct)
-		r.Post("/add-variant/{id}", h.AddVariants)
+		r.With(h.adminForDeleted).Get("/", h.GetAllProducts)
+		r.Get("/search", h.SearchProducts)
+		r.With(h.adminForDeleted).Get("/export", h.ExportProducts)
+		r.With(h.adminForDeleted).Get("/with-variants", h.GetAllProductsWithVariants)
 		r.Get("/{id}", h.GetProductByID)
-		r.Put("/{id}", h.UpdateProduct)
-		r.Delete("/soft/{id}", h.SoftDelete)
-		r.Delete("/hard/{id}", h.HardDelete)
+		r.Get("/{id}/variants", h.GetVariantsByProductID)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.AuthMiddleware.Password)
+			r.Use(h.AuthMiddleware.RequireScopes("products:write"))
+			r.With(h.AuthzMiddleware.RequireRole(user.Admin, user.Regular)).Post("/", h.CreateProduct)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.productOwner, user.Admin)).Post("/add-variant/{id}", h.AddVariants)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.productOwner, user.Admin)).Put("/{id}", h.UpdateProduct)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.productOwner, user.Admin)).Delete("/soft/{id}", h.SoftDelete)
+			r.With(h.AuthzMiddleware.RequireRole(user.Admin)).Delete("/hard/{id}", h.HardDelete)
+			r.With(h.AuthzMiddleware.RequireRole(user.Admin, user.Regular)).Post("/import", h.ImportProducts)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.importOwner, user.Admin)).Get("/import/{importId}", h.GetImportByID)
+		})
+	})
+}
+
+// adminForDeleted only lets Admins list soft deleted Products, requests
+// asking for them having to authenticate. Other list requests stay public.
+func (h *ProductHandler) adminForDeleted(next http.Handler) http.Handler {
+	admin := h.AuthMiddleware.Password(h.AuthzMiddleware.RequireRole(user.Admin)(next))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch pagination.Deleted(strings.ToLower(pagination.ParseQueryParams(r, "deleted"))) {
+		case "", pagination.DeletedExclude:
+			next.ServeHTTP(w, r)
+		default:
+			admin.ServeHTTP(w, r)
+		}
 	})
 }
 
+// productOwner resolves the owner of the Product addressed by the id URL
+// parameter.
+func (h *ProductHandler) productOwner(r *http.Request) (ownerID uuid.UUID, err error) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		err = failure.BadRequest(err)
+		return
+	}
+	return h.ProductService.GetProductOwner(id)
+}
+
+// importOwner resolves the user who started the ProductImport addressed by the
+// importId URL parameter.
+func (h *ProductHandler) importOwner(r *http.Request) (ownerID uuid.UUID, err error) {
+	id, err := uuid.FromString(chi.URLParam(r, "importId"))
+	if err != nil {
+		err = failure.BadRequest(err)
+		return
+	}
+	return h.ProductService.GetImportOwner(id)
+}
+
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	var requestFormat products.PayloadProductAndVariant
@@ -49,7 +112,12 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	prod, err := h.ProductService.CreateWithVariant(requestFormat)
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	prod, err := h.ProductService.CreateWithVariant(requestFormat, userID)
 	if err != nil {
 		response.WithError(w, err)
 		return
@@ -58,30 +126,245 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	response.WithJSON(w, http.StatusCreated, prod)
 }
 
+// GetAllProducts lists Products. Requests with a page are paginated by offset,
+// the others with the cursors found in the previous response.
 func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	page, err := pagination.ConvertToInt(pagination.ParseQueryParams(r, "page"))
+	if pagination.ParseQueryParams(r, "page") == "" {
+		h.getProductsByCursor(w, r)
+		return
+	}
+
+	pg, err := parsePageQuery(r)
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
 
-	limit, err := pagination.ConvertToInt(pagination.ParseQueryParams(r, "limit"))
+	pg.Order, err = pagination.ParseSortQuery(r, products.ProductFields, "productId")
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	pg.Filter, err = pagination.ParseFilterQuery(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	prods, total, err := h.ProductService.GetAllProducts(pg)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	respondPage(w, r, pg, total, prods)
+}
+
+// GetAllProductsWithVariants lists Products along with their live Variants.
+// @Summary List Products with their Variants.
+// @Description This endpoint lists Products like the list endpoint paginated by page, with
+// @Description the live variants of every Product, their images and stock. The page is loaded
+// @Description in a fixed number of queries, whatever its size.
+// @Tags products
+// @Param page query int false "Page number, starting at 1."
+// @Param limit query int false "Page size, 20 by default and 100 at most."
+// @Param sort query string false "Fields to sort by, e.g. -createdAt,productName."
+// @Param deleted query string false "exclude (default), include or only soft deleted Products. Admins only."
+// @Produce json
+// @Success 200 {object} response.Paginated{data=[]products.ProductWithVariantsResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/products/with-variants [get]
+func (h *ProductHandler) GetAllProductsWithVariants(w http.ResponseWriter, r *http.Request) {
+	pg, err := parsePageQuery(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	pg.Order, err = pagination.ParseSortQuery(r, products.ProductFields, "productId")
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	pg.Filter, err = pagination.ParseFilterQuery(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	prods, total, err := h.ProductService.GetAllProductsWithVariants(pg)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	respondPage(w, r, pg, total, prods)
+}
+
+func (h *ProductHandler) getProductsByCursor(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntQuery(r, "limit", defaultLimit)
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
+	if limit < 1 {
+		response.WithError(w, failure.BadRequestFromString("limit must be positive"))
+		return
+	}
+	if limit > maxLimit {
+		response.WithError(w, failure.BadRequestFromString(fmt.Sprintf("limit must be %d at most", maxLimit)))
+		return
+	}
+
+	var cursor *pagination.Cursor
+	if token := pagination.ParseQueryParams(r, "cursor"); token != "" {
+		decoded, err := h.Cursors.Decode(token)
+		if err != nil {
+			response.WithError(w, err)
+			return
+		}
+		cursor = &decoded
+	}
+
+	var sort pagination.Sort
+	if cursor != nil {
+		sort, err = products.ProductFields.ParseSort(cursor.Sort)
+	} else {
+		sort, err = pagination.ParseSortQuery(r, products.ProductFields, "productId")
+	}
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	filter, err := pagination.ParseFilterQuery(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	q := pagination.NewCursorQuery(limit, sort, filter, cursor)
+
+	prods, page, total, err := h.ProductService.GetProductsByCursor(q)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	next, prev := h.encodeCursor(page.Next), h.encodeCursor(page.Prev)
+	meta := pagination.NewMeta(0, limit, total)
+	response.WithCursor(w, http.StatusOK, prods, meta, pagination.NewCursorLinks(r, next, prev), next, prev)
+}
+
+func (h *ProductHandler) encodeCursor(cursor *pagination.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return h.Cursors.Encode(*cursor)
+}
+
+// ExportProducts streams a catalog snapshot.
+// @Summary Export the catalog.
+// @Description This endpoint streams the Products the list endpoint returns, with the same
+// @Description filters and sort, joined with their live Variants and Images. CSV exports
+// @Description have a row per Variant, with the columns of an import spreadsheet, names read
+// @Description as formulas by spreadsheets being prefixed with a quote; NDJSON exports a JSON
+// @Description document per Product and line.
+// @Tags products
+// @Param format query string false "csv (default) or ndjson."
+// @Param sort query string false "Fields to sort by, e.g. -createdAt,productName."
+// @Param deleted query string false "exclude (default), include or only soft deleted Products. Admins only."
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Success 200 {file} file
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/products/export [get]
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format, err := products.ParseExportFormat(pagination.ParseQueryParams(r, "format"))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	sort, err := pagination.ParseSortQuery(r, products.ProductFields, "productId")
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	filter, err := pagination.ParseFilterQuery(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+format.FileName(time.Now())+`"`)
+	cw := &countingWriter{w: w}
+	err = h.ProductService.ExportProducts(filter, sort, format, cw)
+	if err != nil {
+		if cw.n > 0 {
+			// The status is sent already, all that is left is to cut the
+			// export short.
+			logger.ErrorWithStack(err)
+			return
+		}
+		w.Header().Del("Content-Disposition")
+		response.WithError(w, err)
+	}
+}
+
+// countingWriter counts the bytes written through it, telling whether a
+// response has started.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
 
-	sort := pagination.GetSortDirection(pagination.ParseQueryParams(r, "sort"))
-	field := pagination.CheckFieldQuery(pagination.ParseQueryParams(r, "field"), "product_id")
+func (cw *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = cw.w.Write(p)
+	cw.n += int64(n)
+	return
+}
+
+// SearchProducts runs a full-text search over Products.
+// @Summary Search Products.
+// @Description This endpoint searches the names of live Products, of their brand and of their
+// @Description variants, the most relevant first. Results come with their variants and the
+// @Description matching fields, HTML escaped and with the matching words wrapped in <em> tags.
+// @Tags products
+// @Param q query string true "The search query."
+// @Param mode query string false "natural (default) or boolean, to use +word, -word and word*."
+// @Param page query int false "Page number, starting at 1."
+// @Param limit query int false "Page size, 20 by default and 100 at most."
+// @Produce json
+// @Success 200 {object} response.Paginated{data=[]products.ProductSearchResultResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/products/search [get]
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	mode, err := products.ParseSearchMode(pagination.ParseQueryParams(r, "mode"))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
 
-	pg := pagination.NewPaginationQuery(page, limit, field, sort)
+	pg, err := parsePageQuery(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
 
-	prods, err := h.ProductService.GetAllProducts(pg)
+	results, total, err := h.ProductService.SearchProducts(pagination.ParseQueryParams(r, "q"), mode, pg)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
-	response.WithJSON(w, http.StatusOK, prods)
+	respondPage(w, r, pg, total, results)
 }
 
 func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request) {
@@ -103,6 +386,41 @@ func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 	response.WithJSON(w, http.StatusOK, prod)
 }
 
+// GetVariantsByProductID lists the Variants of a Product.
+// @Summary List the Variants of a Product.
+// @Description This endpoint lists the live Variants of a Product, oldest first, with their
+// @Description images and stock.
+// @Tags products
+// @Param id path string true "The Product's identifier."
+// @Param page query int false "Page number, starting at 1."
+// @Param limit query int false "Page size, 20 by default and 100 at most."
+// @Produce json
+// @Success 200 {object} response.Paginated{data=[]variants.VariantResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/products/{id}/variants [get]
+func (h *ProductHandler) GetVariantsByProductID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	pg, err := parsePageQuery(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	varis, total, err := h.VariantService.GetVariantsByProductID(id, pg)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	respondPage(w, r, pg, total, varis)
+}
+
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	idString := chi.URLParam(r, "id")
 	id, err := uuid.FromString(idString)
@@ -125,7 +443,13 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prod, err := h.ProductService.Update(id, requestFormat)
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	prod, err := h.ProductService.Update(id, requestFormat, userID)
 	if err != nil {
 		response.WithError(w, err)
 		return
@@ -141,27 +465,36 @@ func (h *ProductHandler) SoftDelete(w http.ResponseWriter, r *http.Request) {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	decoder := json.NewDecoder(r.Body)
-	var requestFormat products.PayloadProduct
-	err = decoder.Decode(&requestFormat)
+	userID, err := middleware.UserIDFromContext(r.Context())
 	if err != nil {
-		response.WithError(w, failure.BadRequest(err))
+		response.WithError(w, err)
 		return
 	}
-	err = shared.GetValidator().Struct(requestFormat)
+	prod, err := h.ProductService.SoftDelete(id, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, prod)
+}
+
+func (h *ProductHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.FromString(idString)
+
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	prod, err := h.ProductService.SoftDelete(id, requestFormat)
+	err = h.ProductService.HardDelete(id)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
-	response.WithJSON(w, http.StatusOK, prod)
+	response.WithJSON(w, http.StatusNoContent, nil)
 }
 
-func (h *ProductHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
+func (h *ProductHandler) AddVariants(w http.ResponseWriter, r *http.Request) {
 	idString := chi.URLParam(r, "id")
 	id, err := uuid.FromString(idString)
 
@@ -170,7 +503,7 @@ func (h *ProductHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	decoder := json.NewDecoder(r.Body)
-	var requestFormat products.PayloadProduct
+	var requestFormat variants.PayloadVariant
 	err = decoder.Decode(&requestFormat)
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
@@ -181,38 +514,107 @@ func (h *ProductHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	err = h.ProductService.HardDelete(id, requestFormat)
+	userID, err := middleware.UserIDFromContext(r.Context())
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
-	response.WithJSON(w, http.StatusNoContent, nil)
+	vari, err := h.ProductService.AddVariant(id, requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusCreated, vari)
 }
 
-func (h *ProductHandler) AddVariants(w http.ResponseWriter, r *http.Request) {
-	idString := chi.URLParam(r, "id")
-	id, err := uuid.FromString(idString)
+// ImportProducts starts importing Products from a spreadsheet.
+// @Summary Import Products from a spreadsheet.
+// @Description This endpoint stores the CSV or XLSX file sent in the "file" field of a multipart
+// @Description form and imports it in the background. Each row is a Variant, with the columns
+// @Description product_name, brand, variant_name, price and quantity, and optionally
+// @Description limited_threshold, status and images (URLs separated by |). Rows naming a Product
+// @Description of the user by brand and name update it, and rows naming one of its Variants
+// @Description update that Variant. Rejected rows are reported with their line. A dry run only
+// @Description validates the rows and counts what would be written.
+// @Tags products
+// @Security EVMOauthToken
+// @Accept multipart/form-data
+// @Param file formData file true "The CSV or XLSX file."
+// @Param dryRun query bool false "Validate the rows without writing them."
+// @Produce json
+// @Success 202 {object} response.Base{data=products.ProductImportResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/products/import [post]
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if query := pagination.ParseQueryParams(r, "dryRun"); query != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(query)
+		if err != nil {
+			response.WithError(w, failure.BadRequest(err))
+			return
+		}
+	}
 
+	// Leave room for the multipart boundaries and headers around the file.
+	maxSize := h.ProductService.MaxImportSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+(1<<20))
+	err := r.ParseMultipartForm(maxSize)
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	decoder := json.NewDecoder(r.Body)
-	var requestFormat variants.PayloadVariant
-	err = decoder.Decode(&requestFormat)
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	err = shared.GetValidator().Struct(requestFormat)
+	defer file.Close()
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	imp, err := h.ProductService.StartImport(file, header.Filename, header.Size, dryRun, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusAccepted, imp)
+}
+
+// GetImportByID returns the progress of a ProductImport.
+// @Summary Get the progress of a Product import.
+// @Description This endpoint returns the status and counters of a Product import, along with
+// @Description the rows it rejected.
+// @Tags products
+// @Security EVMOauthToken
+// @Param importId path string true "The import's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=products.ProductImportResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/products/import/{importId} [get]
+func (h *ProductHandler) GetImportByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "importId"))
 	if err != nil {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	vari, err := h.ProductService.AddVariant(id, requestFormat)
+	imp, err := h.ProductService.GetImportByID(id)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
-	response.WithJSON(w, http.StatusCreated, vari)
+	response.WithJSON(w, http.StatusOK, imp)
 }
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+)
+
+type UserHandler struct {
+	UserService     user.UserService
+	AuthMiddleware  *middleware.Authentication
+	AuthzMiddleware *middleware.Authorization
+}
+
+func ProvideUserHandler(userService user.UserService, authMiddleware *middleware.Authentication, authzMiddleware *middleware.Authorization) UserHandler {
+	return UserHandler{
+		UserService:     userService,
+		AuthMiddleware:  authMiddleware,
+		AuthzMiddleware: authzMiddleware,
+	}
+}
+
+func (h *UserHandler) Router(r chi.Router) {
+	r.Route("/users", func(r chi.Router) {
+		r.Post("/", h.RegisterUser)
+		r.With(h.AuthMiddleware.Password, h.AuthzMiddleware.RequireOwnerOrRole(h.userOwner, user.Admin)).Get("/{id}", h.GetUserByID)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.AuthMiddleware.Password)
+			r.Use(h.AuthMiddleware.RequireScopes("users:write"))
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.userOwner, user.Admin)).Put("/{id}", h.UpdateUser)
+			r.With(h.AuthzMiddleware.RequireRole(user.Admin)).Put("/{id}/role", h.ChangeRole)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.userOwner, user.Admin)).Delete("/soft/{id}", h.SoftDelete)
+		})
+	})
+}
+
+// userOwner treats a User as owned by itself.
+func (h *UserHandler) userOwner(r *http.Request) (ownerID uuid.UUID, err error) {
+	ownerID, err = uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		err = failure.BadRequest(err)
+	}
+	return
+}
+
+// RegisterUser registers a new User.
+// @Summary Register a new User.
+// @Description This endpoint registers a new User. Users always register as "regular", only
+// @Description an admin can change their role afterwards.
+// @Tags users
+// @Param user body user.PayloadUser true "The User to be registered."
+// @Produce json
+// @Success 201 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/users [post]
+func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat user.PayloadUser
+	err := decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	u, err := h.UserService.Register(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusCreated, u)
+}
+
+// GetUserByID resolves a User by its ID.
+// @Summary Resolve User by ID.
+// @Description This endpoint resolves a User by its ID. Users can only resolve themselves,
+// @Description unless they are an admin.
+// @Tags users
+// @Security EVMOauthToken
+// @Param id path string true "The User's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/users/{id} [get]
+func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	u, err := h.UserService.GetUserByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, u)
+}
+
+// UpdateUser updates a User's username and email.
+// @Summary Update a User.
+// @Description This endpoint updates the username and email of a User.
+// @Tags users
+// @Security EVMOauthToken
+// @Param id path string true "The User's identifier."
+// @Param user body user.PayloadUser true "The User to be updated."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/users/{id} [put]
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat user.PayloadUser
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	u, err := h.UserService.Update(id, requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, u)
+}
+
+// ChangeRole changes the Role of a User.
+// @Summary Change the role of a User.
+// @Description This endpoint changes the role of a User to "admin" or "regular".
+// @Tags users
+// @Security EVMOauthToken
+// @Param id path string true "The User's identifier."
+// @Param role body user.PayloadUserRole true "The new role."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/users/{id}/role [put]
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat user.PayloadUserRole
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	u, err := h.UserService.ChangeRole(id, requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, u)
+}
+
+// SoftDelete marks a User as deleted.
+// @Summary Mark a User as deleted.
+// @Description This endpoint marks a User as deleted by setting its "deletedAt"
+// @Description and "deletedBy" properties.
+// @Tags users
+// @Security EVMOauthToken
+// @Param id path string true "The User's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=user.UserResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/users/soft/{id} [delete]
+func (h *UserHandler) SoftDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	u, err := h.UserService.SoftDelete(id, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, u)
+}
@@ -1 +1,377 @@
 package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/internal/domain/image"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+)
+
+type VariantHandler struct {
+	VariantService  variants.VariantService
+	ImageService    image.ImageService
+	AuthMiddleware  *middleware.Authentication
+	AuthzMiddleware *middleware.Authorization
+}
+
+func ProvideVariantHandler(variantService variants.VariantService, imageService image.ImageService, authMiddleware *middleware.Authentication, authzMiddleware *middleware.Authorization) VariantHandler {
+	return VariantHandler{
+		VariantService:  variantService,
+		ImageService:    imageService,
+		AuthMiddleware:  authMiddleware,
+		AuthzMiddleware: authzMiddleware,
+	}
+}
+
+func (h *VariantHandler) Router(r chi.Router) {
+	r.Route("/variants", func(r chi.Router) {
+		r.Get("/{variantId}", h.GetVariantByID)
+		r.Get("/{variantId}/images", h.GetImagesByVariantID)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.AuthMiddleware.Password)
+			r.Use(h.AuthMiddleware.RequireScopes("products:write"))
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.variantOwner, user.Admin)).Put("/{variantId}", h.UpdateVariant)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.variantOwner, user.Admin)).Delete("/soft/{variantId}", h.SoftDelete)
+			r.With(h.AuthzMiddleware.RequireRole(user.Admin)).Delete("/hard/{variantId}", h.HardDelete)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.variantOwner, user.Admin)).Post("/{variantId}/images", h.UploadImage)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.variantOwner, user.Admin)).Put("/{variantId}/images/order", h.ReorderImages)
+			r.With(h.AuthzMiddleware.RequireOwnerOrRole(h.variantOwner, user.Admin)).Delete("/{variantId}/images/{imageId}", h.DeleteImage)
+		})
+	})
+}
+
+// variantOwner resolves the owner of the Product of the Variant addressed by
+// the variantId URL parameter.
+func (h *VariantHandler) variantOwner(r *http.Request) (ownerID uuid.UUID, err error) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		err = failure.BadRequest(err)
+		return
+	}
+	return h.VariantService.GetVariantOwner(id)
+}
+
+// GetVariantByID resolves a Variant by its ID.
+// @Summary Resolve Variant by ID.
+// @Description This endpoint resolves a Variant by its ID, with its images and stock.
+// @Tags variants
+// @Param variantId path string true "The Variant's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=variants.VariantResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/{variantId} [get]
+func (h *VariantHandler) GetVariantByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	vari, err := h.VariantService.GetVariantByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, vari)
+}
+
+// UpdateVariant updates a Variant.
+// @Summary Update a Variant.
+// @Description This endpoint updates the name, price and quantity of a Variant. Its status
+// @Description is derived from the quantity, so a status sent along must match it. The
+// @Description quantity of a Variant stocked in no or several warehouses can only be changed
+// @Description through the warehouse stock endpoints.
+// @Tags variants
+// @Security EVMOauthToken
+// @Param variantId path string true "The Variant's identifier."
+// @Param variant body variants.PayloadUpdateVariant true "The Variant to be updated."
+// @Produce json
+// @Success 200 {object} response.Base{data=variants.VariantResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/{variantId} [put]
+func (h *VariantHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat variants.PayloadUpdateVariant
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	vari, err := h.VariantService.Update(id, requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, vari)
+}
+
+// SoftDelete marks a Variant as deleted.
+// @Summary Mark a Variant as deleted.
+// @Description This endpoint marks a Variant as deleted. It is then left out of the
+// @Description Product listings and searches.
+// @Tags variants
+// @Security EVMOauthToken
+// @Param variantId path string true "The Variant's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=variants.VariantResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/soft/{variantId} [delete]
+func (h *VariantHandler) SoftDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	vari, err := h.VariantService.SoftDelete(id, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, vari)
+}
+
+// HardDelete permanently deletes a Variant.
+// @Summary Permanently delete a Variant.
+// @Description This endpoint deletes a Variant. Its images and variant_location rows are
+// @Description removed by the cascading foreign keys.
+// @Tags variants
+// @Security EVMOauthToken
+// @Param variantId path string true "The Variant's identifier."
+// @Produce json
+// @Success 204
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/hard/{variantId} [delete]
+func (h *VariantHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.VariantService.HardDelete(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.NoContent(w)
+}
+
+// GetImagesByVariantID resolves the Images of a Variant.
+// @Summary Resolve the Images of a Variant.
+// @Description This endpoint resolves the Images of a Variant, in their display order.
+// @Tags variants
+// @Param variantId path string true "The Variant's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]image.ImageResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/{variantId}/images [get]
+func (h *VariantHandler) GetImagesByVariantID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	imgs, err := h.ImageService.GetImagesByVariantID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, imgs)
+}
+
+// UploadImage uploads an Image for a Variant.
+// @Summary Upload an Image for a Variant.
+// @Description This endpoint stores the file sent in the "image" field of a multipart form
+// @Description and appends it to the Images of a Variant. The content type is detected from
+// @Description the file itself and must be one of the configured image types, and the image
+// @Description must not exceed the configured number of pixels. Its resized
+// @Description renditions are rendered in the background and listed once ready.
+// @Tags variants
+// @Security EVMOauthToken
+// @Param variantId path string true "The Variant's identifier."
+// @Accept multipart/form-data
+// @Param image formData file true "The image file."
+// @Produce json
+// @Success 201 {object} response.Base{data=image.ImageResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/{variantId}/images [post]
+func (h *VariantHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	// Leave room for the multipart boundaries and headers around the file.
+	maxSize := h.ImageService.MaxUploadSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+(1<<20))
+	err = r.ParseMultipartForm(maxSize)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	defer file.Close()
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	img, err := h.ImageService.Upload(id, file, header.Size, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusCreated, img)
+}
+
+// ReorderImages changes the display order of the Images of a Variant.
+// @Summary Reorder the Images of a Variant.
+// @Description This endpoint sets the display order of the Images of a Variant. The payload
+// @Description must list every Image of the Variant exactly once.
+// @Tags variants
+// @Security EVMOauthToken
+// @Param variantId path string true "The Variant's identifier."
+// @Param order body image.PayloadReorder true "The Image IDs in their new order."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]image.ImageResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/{variantId}/images/order [put]
+func (h *VariantHandler) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat image.PayloadReorder
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	imgs, err := h.ImageService.Reorder(id, requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, imgs)
+}
+
+// DeleteImage deletes an Image of a Variant.
+// @Summary Delete an Image of a Variant.
+// @Description This endpoint deletes an Image of a Variant, along with its stored file if it
+// @Description was uploaded.
+// @Tags variants
+// @Security EVMOauthToken
+// @Param variantId path string true "The Variant's identifier."
+// @Param imageId path string true "The Image's identifier."
+// @Produce json
+// @Success 204
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/variants/{variantId}/images/{imageId} [delete]
+func (h *VariantHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "variantId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	imageID, err := uuid.FromString(chi.URLParam(r, "imageId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.ImageService.Delete(id, imageID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.NoContent(w)
+}
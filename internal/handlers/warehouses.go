@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/internal/domain/warehouse"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+)
+
+type WarehouseHandler struct {
+	WarehouseService warehouse.WarehouseService
+	AuthMiddleware   *middleware.Authentication
+	AuthzMiddleware  *middleware.Authorization
+}
+
+func ProvideWarehouseHandler(warehouseService warehouse.WarehouseService, authMiddleware *middleware.Authentication, authzMiddleware *middleware.Authorization) WarehouseHandler {
+	return WarehouseHandler{
+		WarehouseService: warehouseService,
+		AuthMiddleware:   authMiddleware,
+		AuthzMiddleware:  authzMiddleware,
+	}
+}
+
+func (h *WarehouseHandler) Router(r chi.Router) {
+	r.Route("/warehouses", func(r chi.Router) {
+		r.Get("/", h.GetAllWarehouses)
+		r.Get("/{id}", h.GetWarehouseByID)
+		r.Get("/{id}/stock", h.GetStock)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.AuthMiddleware.Password)
+			r.Use(h.AuthMiddleware.RequireScopes("warehouses:write"))
+			r.Use(h.AuthzMiddleware.RequireRole(user.Admin))
+			r.Post("/", h.CreateWarehouse)
+			r.Put("/{id}", h.UpdateWarehouse)
+			r.Delete("/soft/{id}", h.SoftDelete)
+			r.Delete("/hard/{id}", h.HardDelete)
+			r.Put("/{id}/stock/{variantId}", h.AssignStock)
+			r.Patch("/{id}/stock/{variantId}", h.AdjustStock)
+			r.Post("/{id}/stock/{variantId}/move", h.MoveStock)
+		})
+	})
+}
+
+// CreateWarehouse creates a new Warehouse.
+// @Summary Create a new Warehouse.
+// @Description This endpoint creates a new Warehouse.
+// @Tags warehouses
+// @Security EVMOauthToken
+// @Param warehouse body warehouse.PayloadWarehouse true "The Warehouse to be created."
+// @Produce json
+// @Success 201 {object} response.Base{data=warehouse.WarehouseResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses [post]
+func (h *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat warehouse.PayloadWarehouse
+	err := decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	wh, err := h.WarehouseService.Create(requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, wh)
+}
+
+// GetAllWarehouses lists Warehouses.
+// @Summary List Warehouses.
+// @Description This endpoint lists Warehouses ordered by ID.
+// @Tags warehouses
+// @Param page query int false "Page number, starting at 1."
+// @Param limit query int false "Page size, 20 by default and 100 at most."
+// @Param sort query string false "Sort direction, asc or desc."
+// @Produce json
+// @Success 200 {object} response.Paginated{data=[]warehouse.WarehouseResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses [get]
+func (h *WarehouseHandler) GetAllWarehouses(w http.ResponseWriter, r *http.Request) {
+	pg, err := parsePageQuery(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	whs, total, err := h.WarehouseService.GetAllWarehouses(pg)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	respondPage(w, r, pg, total, whs)
+}
+
+// GetWarehouseByID resolves a Warehouse by its ID.
+// @Summary Resolve Warehouse by ID.
+// @Description This endpoint resolves a Warehouse by its ID.
+// @Tags warehouses
+// @Param id path int true "The Warehouse's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=warehouse.WarehouseResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/{id} [get]
+func (h *WarehouseHandler) GetWarehouseByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	wh, err := h.WarehouseService.GetWarehouseByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, wh)
+}
+
+// UpdateWarehouse updates a Warehouse.
+// @Summary Update a Warehouse.
+// @Description This endpoint updates an existing Warehouse.
+// @Tags warehouses
+// @Security EVMOauthToken
+// @Param id path int true "The Warehouse's identifier."
+// @Param warehouse body warehouse.PayloadWarehouse true "The Warehouse to be updated."
+// @Produce json
+// @Success 200 {object} response.Base{data=warehouse.WarehouseResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/{id} [put]
+func (h *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat warehouse.PayloadWarehouse
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	wh, err := h.WarehouseService.Update(id, requestFormat, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, wh)
+}
+
+// SoftDelete marks a Warehouse as deleted.
+// @Summary Mark a Warehouse as deleted.
+// @Description This endpoint marks a Warehouse as deleted. No new stock can be
+// @Description assigned to it, but existing stock can still be moved out.
+// @Tags warehouses
+// @Security EVMOauthToken
+// @Param id path int true "The Warehouse's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=warehouse.WarehouseResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/soft/{id} [delete]
+func (h *WarehouseHandler) SoftDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	userID, err := middleware.UserIDFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	wh, err := h.WarehouseService.SoftDelete(id, userID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, wh)
+}
+
+// HardDelete permanently deletes a Warehouse and the stock held in it.
+// @Summary Permanently delete a Warehouse.
+// @Description This endpoint deletes a Warehouse. Its variant_location rows are
+// @Description removed by the cascading foreign key, their stock recorded as adjusted away
+// @Description and the quantity and status of their variants derived again. Warehouses holding pending
+// @Description reservations cannot be deleted.
+// @Tags warehouses
+// @Security EVMOauthToken
+// @Param id path int true "The Warehouse's identifier."
+// @Produce json
+// @Success 204
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/hard/{id} [delete]
+func (h *WarehouseHandler) HardDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.WarehouseService.HardDelete(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.NoContent(w)
+}
+
+// GetStock lists the Variant quantities held in a Warehouse.
+// @Summary List stock in a Warehouse.
+// @Description This endpoint lists every Variant location of a Warehouse.
+// @Tags warehouses
+// @Param id path int true "The Warehouse's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]variants.LocationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/{id}/stock [get]
+func (h *WarehouseHandler) GetStock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	locs, err := h.WarehouseService.GetStock(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, locs)
+}
+
+// AssignStock sets the quantity of a Variant in a Warehouse.
+// @Summary Assign Variant stock to a Warehouse.
+// @Description This endpoint sets the quantity of a Variant held in a Warehouse
+// @Description and responds with the Variant's stock across all Warehouses. The
+// @Description Variant's status is derived from its new total quantity.
+// @Tags warehouses
+// @Security EVMOauthToken
+// @Param id path int true "The Warehouse's identifier."
+// @Param variantId path string true "The Variant's identifier."
+// @Param stock body warehouse.PayloadAssignStock true "The new quantity."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]variants.LocationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/{id}/stock/{variantId} [put]
+func (h *WarehouseHandler) AssignStock(w http.ResponseWriter, r *http.Request) {
+	id, variantId, err := parseStockParams(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat warehouse.PayloadAssignStock
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	locs, err := h.WarehouseService.AssignStock(id, variantId, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, locs)
+}
+
+// AdjustStock adds to or removes from the quantity of a Variant in a Warehouse.
+// @Summary Adjust Variant stock in a Warehouse.
+// @Description This endpoint adds a positive or negative delta to the quantity of
+// @Description a Variant held in a Warehouse. The quantity can never drop below zero.
+// @Description The Variant's status is derived from its new total quantity.
+// @Tags warehouses
+// @Security EVMOauthToken
+// @Param id path int true "The Warehouse's identifier."
+// @Param variantId path string true "The Variant's identifier."
+// @Param stock body warehouse.PayloadAdjustStock true "The quantity delta."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]variants.LocationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/{id}/stock/{variantId} [patch]
+func (h *WarehouseHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	id, variantId, err := parseStockParams(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat warehouse.PayloadAdjustStock
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	locs, err := h.WarehouseService.AdjustStock(id, variantId, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, locs)
+}
+
+// MoveStock moves Variant stock from one Warehouse to another.
+// @Summary Move Variant stock between Warehouses.
+// @Description This endpoint moves part of a Variant's quantity out of this
+// @Description Warehouse into another one.
+// @Tags warehouses
+// @Security EVMOauthToken
+// @Param id path int true "The source Warehouse's identifier."
+// @Param variantId path string true "The Variant's identifier."
+// @Param stock body warehouse.PayloadMoveStock true "The target Warehouse and quantity."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]variants.LocationResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/warehouses/{id}/stock/{variantId}/move [post]
+func (h *WarehouseHandler) MoveStock(w http.ResponseWriter, r *http.Request) {
+	id, variantId, err := parseStockParams(r)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat warehouse.PayloadMoveStock
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	locs, err := h.WarehouseService.MoveStock(id, variantId, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, locs)
+}
+
+func parseStockParams(r *http.Request) (warehouseId int, variantId uuid.UUID, err error) {
+	warehouseId, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return
+	}
+	variantId, err = uuid.FromString(chi.URLParam(r, "variantId"))
+	return
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/rs/zerolog/log"
 )
 
 var config *configs.Config
@@ -23,9 +24,35 @@ func main() {
 	// Set desired log level
 	logger.SetLogLevel(config)
 
+	// Hash the client secrets still stored in plaintext, which are rejected
+	hashed, err := InitializeOauthToken().HashClientSecrets()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed hashing OAuth client secrets")
+	}
+	if hashed > 0 {
+		log.Info().Int("hashed", hashed).Msg("Hashed plaintext OAuth client secrets")
+	}
+
+	// Derive variant statuses again, the limited threshold may have changed
+	if err := InitializeVariantService().SyncDefaultStatuses(); err != nil {
+		log.Fatal().Err(err).Msg("Failed deriving variant statuses")
+	}
+
 	// Wire everything up
 	http := InitializeService()
 
+	// Release expired stock reservations in the background
+	sweeper := InitializeReservationSweeper()
+	sweeper.Start()
+
+	// Render the renditions of uploaded images in the background
+	renditions := InitializeRenditionWorker()
+	renditions.Start()
+
+	// Run product imports in the background
+	imports := InitializeProductImportWorker()
+	imports.Start()
+
 	// consumers := InitializeEvent()
 
 	// // Start consumers
@@ -0,0 +1,27 @@
+-- variant_location.warehouse_id must match warehouse.warehouse_id (INT) for the
+-- foreign key declared in 04-products.sql to apply.
+ALTER TABLE variant_location MODIFY `warehouse_id` int NOT NULL;
+ALTER TABLE variant_location ADD CONSTRAINT `uq_variant_location` UNIQUE (`variant_id`, `warehouse_id`);
+ALTER TABLE variant_location ADD FOREIGN KEY (`warehouse_id`) REFERENCES `warehouse` (`warehouse_id`) ON DELETE CASCADE;
+CREATE INDEX `idx_variant_location_warehouse` ON variant_location (`warehouse_id`);
+
+-- New variants start out in the oldest active warehouse instead of a random
+-- one. Stock can then be moved through the /v1/warehouses endpoints.
+DROP TRIGGER IF EXISTS tr_insert_variant_location;
+
+DELIMITER //
+CREATE TRIGGER tr_insert_variant_location
+AFTER INSERT ON variant
+FOR EACH ROW
+BEGIN
+    DECLARE defaultWarehouse INT;
+    SET defaultWarehouse = (SELECT MIN(warehouse_id) FROM warehouse WHERE deleted_at IS NULL);
+
+    IF defaultWarehouse IS NOT NULL THEN
+        INSERT INTO variant_location (variant_id, warehouse_id, variant_quantity)
+        VALUES (NEW.variant_id, defaultWarehouse, NEW.quantity);
+    END IF;
+END;
+//
+
+DELIMITER ;
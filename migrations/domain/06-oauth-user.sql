@@ -0,0 +1,2 @@
+-- Access tokens identify the acting user by user.user_id, which is a UUID.
+ALTER TABLE oauth_access_tokens MODIFY `user_id` VARCHAR(36) NULL;
@@ -0,0 +1,3 @@
+-- The password grant of /oauth/token authenticates against the user table.
+-- Passwords are stored as bcrypt hashes.
+ALTER TABLE `user` ADD COLUMN `password` VARCHAR(60) NOT NULL DEFAULT '' AFTER `role`;
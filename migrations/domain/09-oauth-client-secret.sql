@@ -0,0 +1,8 @@
+-- Client secrets are stored as bcrypt hashes. The seeded client_web secret is
+-- re-hashed here; any other plaintext secret is hashed when the service starts,
+-- before it serves requests, as plaintext secrets are rejected.
+ALTER TABLE oauth_clients MODIFY `client_secret` VARCHAR(60) NOT NULL;
+
+UPDATE oauth_clients
+SET client_secret = '$2a$10$rt9ErGSUaQ3NfDT0G82p3.ORgQzXwdaiB7x9.WV2jb1lSaLhvoSCK'
+WHERE client_id = 'client_web' AND client_secret = '3v3rm0s';
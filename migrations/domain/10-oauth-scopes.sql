@@ -0,0 +1,5 @@
+-- Scopes are space delimited. Tokens may request any subset of the scope of
+-- their client and get all of it when requesting none.
+UPDATE oauth_clients
+SET scope = 'products:write brands:write warehouses:write users:write clients:write'
+WHERE client_id = 'client_web' AND scope = 'user';
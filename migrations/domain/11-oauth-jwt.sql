@@ -0,0 +1,9 @@
+-- JWT access tokens are not stored. Revoking one denylists its jti until the
+-- token expires.
+CREATE TABLE IF NOT EXISTS `oauth_revoked_tokens` (
+    `jti` VARCHAR(40) NOT NULL,
+    `expires` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`jti`),
+    INDEX `idx_oauth_revoked_tokens_expires` (`expires`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
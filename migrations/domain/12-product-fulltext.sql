@@ -0,0 +1,5 @@
+-- GET /v1/products/search matches products by their name, the name of their
+-- brand and the names of their variants.
+ALTER TABLE `product` ADD FULLTEXT INDEX `ft_product_name` (`product_name`);
+ALTER TABLE `brand` ADD FULLTEXT INDEX `ft_brand_name` (`brand_name`);
+ALTER TABLE `variant` ADD FULLTEXT INDEX `ft_variant_name` (`variant_name`);
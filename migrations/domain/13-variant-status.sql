@@ -0,0 +1,14 @@
+-- Variant statuses are derived from their quantity. Variants holding
+-- limited_threshold units or less are limited; products without a threshold
+-- use VARIANT.LIMITED_THRESHOLD. The backfill below assumes the 5 of .env.example,
+-- the service deriving those statuses again from the configured value when it
+-- starts.
+ALTER TABLE `product` ADD `limited_threshold` int NULL DEFAULT NULL AFTER `product_name`;
+
+UPDATE variant
+JOIN product ON product.product_id = variant.product_id
+SET variant.status = CASE
+    WHEN variant.quantity <= 0 THEN 'out_of_stock'
+    WHEN variant.quantity <= COALESCE(product.limited_threshold, 5) THEN 'limited'
+    ELSE 'ready'
+END;
@@ -0,0 +1,33 @@
+-- Reservations take stock out of a warehouse until they are committed, when
+-- the stock is sold, or released, when it is put back. Reservations left
+-- pending past expires_at are released by the sweeper as expired.
+CREATE TABLE `stock_reservation` (
+  `reservation_id` char(36) PRIMARY KEY NOT NULL,
+  `variant_id` char(36) NOT NULL,
+  `warehouse_id` int NOT NULL,
+  `quantity` int NOT NULL,
+  `status` ENUM ('reserved', 'committed', 'released', 'expired') NOT NULL,
+  `client_id` varchar(255) NOT NULL,
+  `expires_at` timestamp NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX `idx_stock_reservation_expiry` ON `stock_reservation` (`status`, `expires_at`);
+ALTER TABLE `stock_reservation` ADD FOREIGN KEY (`variant_id`) REFERENCES `variant` (`variant_id`) ON DELETE CASCADE;
+
+-- stock_movement is the ledger of the stock of each variant in each warehouse.
+-- quantity is the signed change to that stock: reservations take stock out,
+-- releases and expiries put it back, and commits, which settle stock already
+-- taken out, change nothing.
+CREATE TABLE `stock_movement` (
+  `stock_movement_id` bigint AUTO_INCREMENT PRIMARY KEY NOT NULL,
+  `variant_id` char(36) NOT NULL,
+  `warehouse_id` int NOT NULL,
+  `reservation_id` char(36) NULL DEFAULT NULL,
+  `kind` ENUM ('assign', 'adjust', 'move', 'reserve', 'commit', 'release', 'expire') NOT NULL,
+  `quantity` int NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX `idx_stock_movement_variant` ON `stock_movement` (`variant_id`, `created_at`);
@@ -0,0 +1,9 @@
+-- Images are either uploaded, when blob_key locates them in the blob store, or
+-- linked by URL. position orders the images of a variant.
+ALTER TABLE `image` ADD `position` int NOT NULL DEFAULT 0 AFTER `image_url`;
+ALTER TABLE `image` ADD `blob_key` varchar(255) NULL DEFAULT NULL AFTER `position`;
+ALTER TABLE `image` ADD `content_type` varchar(100) NULL DEFAULT NULL AFTER `blob_key`;
+ALTER TABLE `image` ADD `size` bigint NULL DEFAULT NULL AFTER `content_type`;
+ALTER TABLE `image` MODIFY `image_url` varchar(500) NOT NULL;
+
+CREATE INDEX `idx_image_position` ON `image` (`variant_id`, `position`);
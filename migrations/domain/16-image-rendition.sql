@@ -0,0 +1,16 @@
+-- Renditions are resized copies of uploaded images, rendered in the background
+-- once an image is uploaded. Each image has at most one rendition per width and
+-- format.
+CREATE TABLE `image_rendition` (
+  `image_id` char(36) NOT NULL,
+  `width` int NOT NULL,
+  `height` int NOT NULL,
+  `format` ENUM ('jpeg', 'webp') NOT NULL,
+  `blob_key` varchar(255) NOT NULL,
+  `url` varchar(500) NOT NULL,
+  `size` bigint NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`image_id`, `width`, `format`)
+);
+
+ALTER TABLE `image_rendition` ADD FOREIGN KEY (`image_id`) REFERENCES `image` (`image_id`) ON DELETE CASCADE;
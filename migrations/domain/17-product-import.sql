@@ -0,0 +1,38 @@
+-- product_import tracks the background jobs importing Products from uploaded
+-- spreadsheets. Dry runs validate and count the rows without writing them.
+CREATE TABLE `product_import` (
+  `import_id` char(36) PRIMARY KEY NOT NULL,
+  `user_id` char(36) NOT NULL,
+  `file_name` varchar(255) NOT NULL,
+  `format` ENUM ('csv', 'xlsx') NOT NULL,
+  `blob_key` varchar(255) NOT NULL,
+  `dry_run` boolean NOT NULL DEFAULT false,
+  `status` ENUM ('pending', 'running', 'succeeded', 'failed') NOT NULL,
+  `message` varchar(500) NULL DEFAULT NULL,
+  `total_rows` int NOT NULL DEFAULT 0,
+  `failed_rows` int NOT NULL DEFAULT 0,
+  `created_products` int NOT NULL DEFAULT 0,
+  `updated_products` int NOT NULL DEFAULT 0,
+  `created_variants` int NOT NULL DEFAULT 0,
+  `updated_variants` int NOT NULL DEFAULT 0,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `finished_at` timestamp NULL DEFAULT NULL
+);
+
+CREATE INDEX `idx_product_import_user` ON `product_import` (`user_id`, `created_at`);
+ALTER TABLE `product_import` ADD FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON DELETE CASCADE;
+
+-- product_import_error reports why rows of an import were rejected. line is
+-- the line of the row in the spreadsheet, the header being line 1.
+CREATE TABLE `product_import_error` (
+  `product_import_error_id` bigint AUTO_INCREMENT PRIMARY KEY NOT NULL,
+  `import_id` char(36) NOT NULL,
+  `line` int NOT NULL,
+  `message` varchar(500) NOT NULL
+);
+
+ALTER TABLE `product_import_error` ADD FOREIGN KEY (`import_id`) REFERENCES `product_import` (`import_id`) ON DELETE CASCADE;
+
+-- Imports match Products by owner, brand and name.
+CREATE INDEX `idx_product_owner_brand_name` ON `product` (`user_id`, `brand_id`, `product_name`);
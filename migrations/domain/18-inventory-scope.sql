@@ -0,0 +1,5 @@
+-- The reservation endpoints require the inventory:write scope, which the web
+-- client is granted along with the others of migration 10.
+UPDATE oauth_clients
+SET scope = CONCAT(scope, ' inventory:write')
+WHERE client_id = 'client_web' AND CONCAT(' ', scope, ' ') NOT LIKE '% inventory:write %';
@@ -0,0 +1,38 @@
+// Package blob stores binary objects, such as uploaded images, behind a
+// pluggable Store.
+package blob
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when reading a key that holds no object.
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps objects under slash separated keys.
+type Store interface {
+	// Put stores body under key, replacing any previous object.
+	Put(key string, body io.ReadSeeker, contentType string) error
+	// Get opens the object stored under key.
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Missing keys are ignored.
+	Delete(key string) error
+	// URL returns the public URL of the object stored under key.
+	URL(key string) string
+}
+
+// CleanKey normalizes key and rejects keys escaping the root of a Store.
+func CleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid blob key " + key)
+	}
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
+
+func joinURL(baseURL, key string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
@@ -0,0 +1,43 @@
+package blob_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/blob"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "blob")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	store := blob.NewLocalStore(root, "http://localhost:8080/blobs/")
+	assert.NoError(t, store.Put("images/a.png", strings.NewReader("png"), "image/png"))
+
+	r, err := store.Get("images/a.png")
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "png", string(body))
+	assert.Equal(t, "http://localhost:8080/blobs/images/a.png", store.URL("images/a.png"))
+
+	assert.NoError(t, store.Delete("images/a.png"))
+	assert.NoError(t, store.Delete("images/a.png"))
+	_, err = store.Get("images/a.png")
+	assert.Equal(t, blob.ErrNotFound, err)
+}
+
+func TestCleanKey(t *testing.T) {
+	key, err := blob.CleanKey("/images//a.png")
+	assert.NoError(t, err)
+	assert.Equal(t, "images/a.png", key)
+
+	_, err = blob.CleanKey("../etc/passwd")
+	assert.Error(t, err)
+	_, err = blob.CleanKey("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package blob
+
+import (
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Config locates an S3 bucket. Endpoint is only set for S3 compatible
+// services, such as a local MinIO, which are then addressed path style.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	BaseURL         string
+}
+
+// S3Store keeps objects in an S3 bucket. Their URLs start with BaseURL, which
+// should point to the bucket or to a CDN in front of it.
+type S3Store struct {
+	client  *s3.S3
+	bucket  string
+	baseURL string
+}
+
+func NewS3Store(config S3Config) (*S3Store, error) {
+	awsConfig := aws.NewConfig().WithRegion(config.Region)
+	if config.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(config.AccessKeyID, config.SecretAccessKey, ""))
+	}
+	if config.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(config.Endpoint).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Store{
+		client:  s3.New(sess),
+		bucket:  config.Bucket,
+		baseURL: config.BaseURL,
+	}, nil
+}
+
+func (s *S3Store) Put(key string, body io.ReadSeeker, contentType string) (err error) {
+	key, err = CleanKey(key)
+	if err != nil {
+		return
+	}
+	_, err = s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	return
+}
+
+func (s *S3Store) Get(key string) (io.ReadCloser, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3Store) Delete(key string) (err error) {
+	key, err = CleanKey(key)
+	if err != nil {
+		return
+	}
+	_, err = s.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return
+}
+
+func (s *S3Store) URL(key string) string {
+	return joinURL(s.baseURL, key)
+}
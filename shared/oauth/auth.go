@@ -1,14 +1,34 @@
 package oauth
 
 import (
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/guregu/null"
 	"github.com/jmoiron/sqlx"
 )
 
 type GrantType string
 
+// TokenTypeHint tells revocation and introspection which kind of token to
+// look up first, as described in RFC 7009 section 2.1.
+type TokenTypeHint string
+
 const (
 	ClientCredentials GrantType = "client_credentials"
 	Password          GrantType = "password"
+	RefreshToken      GrantType = "refresh_token"
+
+	AccessTokenHint  TokenTypeHint = "access_token"
+	RefreshTokenHint TokenTypeHint = "refresh_token"
+)
+
+// DefaultExpiration and DefaultRefreshExpiration are the lifetimes in seconds
+// of access and refresh tokens created with a Config that does not set them.
+const (
+	DefaultExpiration        int64 = 3600
+	DefaultRefreshExpiration int64 = 14 * 24 * 3600
 )
 
 type Token struct {
@@ -17,15 +37,42 @@ type Token struct {
 }
 
 func New(db *sqlx.DB, config Config) *Token {
+	if config.Expiration <= 0 {
+		config.Expiration = DefaultExpiration
+	}
+	if config.RefreshExpiration <= 0 {
+		config.RefreshExpiration = DefaultRefreshExpiration
+	}
+	if config.Format == "" {
+		config.Format = Opaque
+	}
+
+	tokenStore := NewTokenStore(db)
+	if config.Cache != nil {
+		tokenStore = tokenStore.WithCache(config.Cache)
+	}
+
 	return &Token{
 		config:          config,
-		tokenRepository: NewTokenStore(db),
+		tokenRepository: tokenStore,
 	}
 }
 
 type Config struct {
-	Expiration  int64
-	ClientScope []string
+	Expiration        int64
+	RefreshExpiration int64
+	ClientScope       []string
+
+	// Format selects opaque or JWT access tokens. JWTs are signed with Keys
+	// and carry Issuer. Keys also allows validating JWTs while issuing
+	// opaque tokens, for instance when switching back from JWT.
+	Format TokenFormat
+	Issuer string
+	Keys   *KeySet
+
+	// Cache, when set, keeps resolved opaque access tokens and the revocation
+	// status of JWTs in Redis.
+	Cache *TokenCache
 }
 
 // Create is function to store NewToken into database
@@ -38,9 +85,226 @@ func (t *Token) Create(credential Credential) (*TokenResponse, error) {
 	return grant.toCreateTokenResponse(), nil
 }
 
+// Revoke invalidates an access or refresh token issued to the client of
+// credential, following RFC 7009. Unknown tokens and tokens of other clients
+// are ignored, so only client authentication failures are reported.
+func (t *Token) Revoke(credential Credential) error {
+	_, err := authenticateClient(t.tokenRepository, credential)
+	if err != nil {
+		return err
+	}
+
+	if credential.Token == "" {
+		return NewError(ErrorCodeInvalidRequest, ErrorMissingToken)
+	}
+
+	if t.isJWT(credential.Token) {
+		return t.revokeJWT(credential)
+	}
+
+	revoke := []func(token string, clientID string) (bool, error){
+		t.tokenRepository.deleteAccessTokenOfClient,
+		t.tokenRepository.deleteRefreshTokenOfClient,
+	}
+	if credential.TokenTypeHint == RefreshTokenHint {
+		revoke[0], revoke[1] = revoke[1], revoke[0]
+	}
+
+	for _, r := range revoke {
+		deleted, err := r(credential.Token, credential.ClientID)
+		if err != nil || deleted {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Introspect describes an access or refresh token to an authenticated client,
+// following RFC 7662. Unknown and expired tokens are reported as inactive.
+func (t *Token) Introspect(credential Credential) (*IntrospectionResponse, error) {
+	_, err := authenticateClient(t.tokenRepository, credential)
+	if err != nil {
+		return &IntrospectionResponse{}, err
+	}
+
+	if credential.Token == "" {
+		return &IntrospectionResponse{}, NewError(ErrorCodeInvalidRequest, ErrorMissingToken)
+	}
+
+	introspect := []func(token string) (*IntrospectionResponse, error){
+		t.introspectAccessToken,
+		t.introspectRefreshToken,
+	}
+	if credential.TokenTypeHint == RefreshTokenHint {
+		introspect[0], introspect[1] = introspect[1], introspect[0]
+	}
+
+	for _, i := range introspect {
+		resp, err := i(credential.Token)
+		if err != nil || resp.Active {
+			return resp, err
+		}
+	}
+
+	return &IntrospectionResponse{}, nil
+}
+
+func (t *Token) introspectAccessToken(token string) (*IntrospectionResponse, error) {
+	if t.isJWT(token) {
+		accessToken, err := NewParser(t.tokenRepository, t.config).parseJWT(token)
+		if err != nil {
+			// Invalid, expired and revoked tokens are all inactive.
+			return &IntrospectionResponse{}, nil
+		}
+		return &IntrospectionResponse{
+			Active:    true,
+			ClientID:  accessToken.ClientID,
+			UserID:    accessToken.UserID.String,
+			Scope:     accessToken.Scope.String,
+			Exp:       accessToken.Expires.Unix(),
+			TokenType: string(Bearer),
+		}, nil
+	}
+
+	accessToken, found, err := t.tokenRepository.findAccessToken(token)
+	if err != nil || !found || !accessToken.VerifyExpireIn() {
+		return &IntrospectionResponse{}, err
+	}
+
+	return &IntrospectionResponse{
+		Active:    true,
+		ClientID:  accessToken.ClientID,
+		UserID:    accessToken.UserID.String,
+		Scope:     accessToken.Scope.String,
+		Exp:       accessToken.Expires.Unix(),
+		TokenType: string(Bearer),
+	}, nil
+}
+
+func (t *Token) introspectRefreshToken(token string) (*IntrospectionResponse, error) {
+	refreshToken, found, err := t.tokenRepository.findRefreshToken(token)
+	if err != nil || !found || !refreshToken.VerifyExpireIn() {
+		return &IntrospectionResponse{}, err
+	}
+
+	return &IntrospectionResponse{
+		Active:    true,
+		ClientID:  refreshToken.ClientID,
+		UserID:    refreshToken.UserID.String,
+		Scope:     refreshToken.Scope.String,
+		Exp:       refreshToken.Expires.Unix(),
+		TokenType: string(RefreshTokenHint),
+	}, nil
+}
+
+// CreateClient registers a new client and returns its generated secret.
+func (t *Token) CreateClient(registration ClientRegistration) (*ClientCredentialResponse, error) {
+	_, err := t.tokenRepository.resolveClientByClientID(registration.ClientID)
+	if err == nil {
+		return &ClientCredentialResponse{}, failure.Conflict("create", "OauthClient", "client_id already taken")
+	}
+	if _, ok := err.(*Error); !ok {
+		return &ClientCredentialResponse{}, failure.InternalError(err)
+	}
+
+	secret, hash, err := generateClientSecret()
+	if err != nil {
+		return &ClientCredentialResponse{}, failure.InternalError(err)
+	}
+
+	grantTypes := make([]string, len(registration.GrantTypes))
+	for i, g := range registration.GrantTypes {
+		grantTypes[i] = string(g)
+	}
+
+	scope := strings.Join(ParseScope(registration.Scope), " ")
+	err = t.tokenRepository.createClient(OauthClient{
+		ClientID:     registration.ClientID,
+		ClientSecret: hash,
+		RedirectURI:  registration.RedirectURI,
+		GrantTypes:   strings.Join(grantTypes, " "),
+		Scope:        null.NewString(scope, scope != ""),
+	})
+	if err != nil {
+		return &ClientCredentialResponse{}, failure.InternalError(err)
+	}
+
+	return &ClientCredentialResponse{ClientID: registration.ClientID, ClientSecret: secret}, nil
+}
+
+// RotateClientSecret replaces the secret of a client with a newly generated
+// one. The previous secret stops working immediately.
+func (t *Token) RotateClientSecret(clientID string) (*ClientCredentialResponse, error) {
+	secret, hash, err := generateClientSecret()
+	if err != nil {
+		return &ClientCredentialResponse{}, failure.InternalError(err)
+	}
+
+	updated, err := t.tokenRepository.updateClientSecret(clientID, hash)
+	if err != nil {
+		return &ClientCredentialResponse{}, failure.InternalError(err)
+	}
+	if !updated {
+		return &ClientCredentialResponse{}, failure.NotFound("oauth client")
+	}
+
+	return &ClientCredentialResponse{ClientID: clientID, ClientSecret: secret}, nil
+}
+
+// HashClientSecrets replaces the legacy plaintext secrets of clients by their
+// hash, which is all VerifyClient accepts. It is run on startup, before
+// serving any request.
+func (t *Token) HashClientSecrets() (hashed int, err error) {
+	clients, err := t.tokenRepository.resolveAllClients()
+	if err != nil {
+		return
+	}
+
+	for _, client := range clients {
+		if client.HasHashedSecret() {
+			continue
+		}
+
+		hash, err := HashClientSecret(client.ClientSecret)
+		if err != nil {
+			return hashed, err
+		}
+		if _, err := t.tokenRepository.updateClientSecret(client.ClientID, hash); err != nil {
+			return hashed, err
+		}
+		hashed++
+	}
+
+	return
+}
+
 // ParseWithAccessToken is function to exchange valid token into token info
 func (t *Token) ParseWithAccessToken(accessToken string) (OauthAccessToken, error) {
-	return NewParser(t.tokenRepository).Parse(accessToken)
+	return NewParser(t.tokenRepository, t.config).Parse(accessToken)
+}
+
+// JWKS returns the public keys JWT access tokens can be verified with.
+func (t *Token) JWKS() JSONWebKeySet {
+	if t.config.Keys == nil {
+		return JSONWebKeySet{Keys: []JSONWebKey{}}
+	}
+	return t.config.Keys.JWKS()
+}
+
+func (t *Token) isJWT(token string) bool {
+	return t.config.Keys != nil && isJWT(token)
+}
+
+// revokeJWT denylists the jti of a JWT access token issued to the client of
+// credential until the token expires.
+func (t *Token) revokeJWT(credential Credential) error {
+	claims, err := t.config.Keys.Verify(credential.Token)
+	if err != nil || claims.ClientID != credential.ClientID {
+		return nil
+	}
+
+	return t.tokenRepository.createRevokedToken(claims.ID, time.Unix(claims.ExpiresAt, 0))
 }
 
 // ClientScopeAllowed is function that is used to limit the client
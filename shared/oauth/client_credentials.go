@@ -1,7 +1,7 @@
 package oauth
 
 import (
-	"errors"
+	"github.com/guregu/null"
 )
 
 type ClientCredentialsAuth struct {
@@ -10,27 +10,16 @@ type ClientCredentialsAuth struct {
 }
 
 func (c *ClientCredentialsAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
-	client, err := c.tokenStore.resolveClientByClientID(credential.ClientID)
+	client, err := resolveClient(c.tokenStore, credential)
 	if err != nil {
 		return
 	}
 
-	if !client.VerifyClient(credential) {
-		err = errors.New(ErrorInvalidClient)
-		return
-	}
-
-	accessToken, err := generateAccessToken()
-	if err != nil {
-		err = errors.New(ErrorGenerateAccessToken)
-		return
-	}
-
-	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, nil, true, c.config)
-	err = c.tokenStore.createAccessToken(oauthAccessToken)
+	scope, err := grantScope(credential.Scope, client.Scope)
 	if err != nil {
 		return
 	}
 
+	oauthAccessToken, err = issueAccessToken(c.tokenStore, c.config, credential.ClientID, null.String{}, scope)
 	return
 }
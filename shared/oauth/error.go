@@ -1,11 +1,51 @@
 package oauth
 
 const (
-	ErrorEmptyCredential     string = "Credential can't be empty"
-	ErrorClientNotFound      string = "Client does not exist"
-	ErrorInvalidPassword     string = "Invalid password credential"
-	ErrorInvalidClient       string = "Invalid client credentials"
-	ErrorInvalidToken        string = "Invalid Token"
-	ErrorTokenTypeMismatch   string = "Token type mismatch"
-	ErrorGenerateAccessToken string = "Error generating access token"
+	ErrorEmptyCredential         string = "Credential can't be empty"
+	ErrorClientNotFound          string = "Client does not exist"
+	ErrorInvalidPassword         string = "Invalid password credential"
+	ErrorInvalidClient           string = "Invalid client credentials"
+	ErrorInvalidToken            string = "Invalid Token"
+	ErrorTokenTypeMismatch       string = "Token type mismatch"
+	ErrorGenerateAccessToken     string = "Error generating access token"
+	ErrorUserNotFound            string = "User does not exist"
+	ErrorUnsupportedGrantType    string = "Grant type is not supported"
+	ErrorUnauthorizedGrantType   string = "Client is not allowed to use this grant type"
+	ErrorClientScopeNotAllowed   string = "Client is not allowed to request tokens"
+	ErrorMissingGrantType        string = "Missing grant_type parameter"
+	ErrorMissingClientCredential string = "Missing client_id parameter"
+	ErrorMissingRefreshToken     string = "Missing refresh_token parameter"
+	ErrorInvalidRefreshToken     string = "Invalid or expired refresh token"
+	ErrorGenerateRefreshToken    string = "Error generating refresh token"
+	ErrorMissingToken            string = "Missing token parameter"
+	ErrorInvalidScope            string = "Requested scope exceeds the granted scope"
+	ErrorRevokedToken            string = "Token has been revoked"
 )
+
+// Error codes defined in RFC 6749 section 5.2.
+const (
+	ErrorCodeInvalidRequest       string = "invalid_request"
+	ErrorCodeInvalidClient        string = "invalid_client"
+	ErrorCodeInvalidGrant         string = "invalid_grant"
+	ErrorCodeUnauthorizedClient   string = "unauthorized_client"
+	ErrorCodeUnsupportedGrantType string = "unsupported_grant_type"
+	ErrorCodeInvalidScope         string = "invalid_scope"
+	ErrorCodeServerError          string = "server_error"
+)
+
+// Error is an OAuth error response as described in RFC 6749 section 5.2.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func NewError(code string, description string) *Error {
+	return &Error{
+		Code:        code,
+		Description: description,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
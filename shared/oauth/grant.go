@@ -1,5 +1,12 @@
 package oauth
 
+import (
+	"strings"
+	"time"
+
+	"github.com/guregu/null"
+)
+
 type AuthorizationMethod interface {
 	Create(credential Credential) (OauthAccessToken, error)
 }
@@ -20,6 +27,118 @@ func (g *Grant) Create(credential Credential) (OauthAccessToken, error) {
 	authMap := make(map[GrantType]AuthorizationMethod)
 	authMap[ClientCredentials] = &ClientCredentialsAuth{tokenStore: g.TokenStore, config: g.Config}
 	authMap[Password] = &PasswordAuth{tokenStore: g.TokenStore, config: g.Config}
+	authMap[RefreshToken] = &RefreshTokenAuth{tokenStore: g.TokenStore, config: g.Config}
+
+	method, ok := authMap[credential.GrantType]
+	if !ok {
+		return OauthAccessToken{}, NewError(ErrorCodeUnsupportedGrantType, ErrorUnsupportedGrantType)
+	}
+
+	return method.Create(credential)
+}
+
+// authenticateClient resolves the client of a credential and checks its secret.
+func authenticateClient(tokenStore TokenStore, credential Credential) (client OauthClient, err error) {
+	client, err = tokenStore.resolveClientByClientID(credential.ClientID)
+	if err != nil {
+		return
+	}
+
+	if !client.VerifyClient(credential) {
+		err = NewError(ErrorCodeInvalidClient, ErrorInvalidClient)
+		return
+	}
+
+	return
+}
+
+// resolveClient authenticates the client of a credential and makes sure it
+// may use the requested grant type.
+func resolveClient(tokenStore TokenStore, credential Credential) (client OauthClient, err error) {
+	client, err = authenticateClient(tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	if !client.AllowsGrantType(credential.GrantType) {
+		err = NewError(ErrorCodeUnauthorizedClient, ErrorUnauthorizedGrantType)
+		return
+	}
+
+	return
+}
+
+// issueAccessToken creates the access token of a grant. Opaque tokens are
+// stored in oauth_access_tokens, JWTs are only signed.
+func issueAccessToken(tokenStore TokenStore, config Config, clientID string, userID null.String, scope null.String) (oauthAccessToken OauthAccessToken, err error) {
+	token, err := generateAccessToken()
+	if err != nil {
+		err = NewError(ErrorCodeServerError, ErrorGenerateAccessToken)
+		return
+	}
+
+	oauthAccessToken = new(OauthAccessToken).Generate(token, clientID, userID, scope, config)
+	if config.Format != JWT {
+		err = tokenStore.createAccessToken(oauthAccessToken)
+		return
+	}
+
+	if config.Keys == nil {
+		err = NewError(ErrorCodeServerError, ErrorGenerateAccessToken)
+		return
+	}
+
+	// The opaque token doubles as the jti, which revocation denylists.
+	signed, err := config.Keys.Sign(Claims{
+		Issuer:    config.Issuer,
+		Subject:   userID.String,
+		ClientID:  clientID,
+		Scope:     scope.String,
+		ExpiresAt: oauthAccessToken.Expires.Unix(),
+		IssuedAt:  time.Now().Unix(),
+		ID:        token,
+	})
+	if err != nil {
+		err = NewError(ErrorCodeServerError, ErrorGenerateAccessToken)
+		return
+	}
+
+	oauthAccessToken.AccessToken = signed
+	return
+}
+
+// grantScope resolves the scope of a new token. A requested scope must be
+// covered by allowed, and requesting nothing grants all of allowed.
+func grantScope(requested string, allowed null.String) (scope null.String, err error) {
+	requestedScopes := ParseScope(requested)
+	if len(requestedScopes) == 0 {
+		return allowed, nil
+	}
+
+	if !scopeCovers(allowed.String, requestedScopes...) {
+		err = NewError(ErrorCodeInvalidScope, ErrorInvalidScope)
+		return
+	}
+
+	return null.StringFrom(strings.Join(requestedScopes, " ")), nil
+}
+
+// issueRefreshToken stores a new refresh token for accessToken and attaches it
+// to the access token so it is part of the token response. The refresh token
+// is granted scope, which may be wider than the scope of accessToken.
+func issueRefreshToken(tokenStore TokenStore, accessToken *OauthAccessToken, scope null.String, config Config) (err error) {
+	token, err := generateAccessToken()
+	if err != nil {
+		return NewError(ErrorCodeServerError, ErrorGenerateRefreshToken)
+	}
+
+	refreshToken := new(OauthRefreshToken).Generate(token, *accessToken, config)
+	refreshToken.Scope = scope
+	err = tokenStore.createRefreshToken(refreshToken)
+	if err != nil {
+		return
+	}
 
-	return authMap[credential.GrantType].Create(credential)
+	accessToken.RefreshToken = refreshToken.RefreshToken
+	return
 }
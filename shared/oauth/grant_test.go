@@ -0,0 +1,57 @@
+package oauth_test
+
+import (
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrant(t *testing.T) {
+	t.Run("unsupported grant type", func(t *testing.T) {
+		grant := oauth.NewGrant(oauth.TokenStore{}, oauth.Config{})
+
+		_, err := grant.Create(oauth.Credential{GrantType: "implicit", ClientID: "client_web"})
+		oauthErr, ok := err.(*oauth.Error)
+		assert.True(t, ok)
+		assert.Equal(t, oauth.ErrorCodeUnsupportedGrantType, oauthErr.Code)
+	})
+
+	t.Run("client grant types", func(t *testing.T) {
+		client := oauth.OauthClient{GrantTypes: "client_credentials password refresh_token"}
+
+		assert.True(t, client.AllowsGrantType(oauth.RefreshToken))
+		assert.False(t, client.AllowsGrantType("implicit"))
+	})
+}
+
+func TestOauthClient(t *testing.T) {
+	hash, err := oauth.HashClientSecret("s3cret")
+	assert.NoError(t, err)
+
+	t.Run("hashed secret", func(t *testing.T) {
+		client := oauth.OauthClient{ClientID: "client_web", ClientSecret: hash}
+
+		assert.True(t, client.HasHashedSecret())
+		assert.True(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "s3cret"}))
+		assert.False(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: hash}))
+	})
+
+	t.Run("legacy plaintext secret", func(t *testing.T) {
+		client := oauth.OauthClient{ClientID: "client_web", ClientSecret: "s3cret"}
+
+		assert.False(t, client.HasHashedSecret())
+		assert.False(t, client.VerifyClient(oauth.Credential{ClientID: "client_web", ClientSecret: "s3cret"}))
+	})
+}
+
+func TestScope(t *testing.T) {
+	token := oauth.OauthAccessToken{Scope: null.StringFrom("products:write  brands:write")}
+
+	assert.Equal(t, []string{"products:write", "brands:write"}, oauth.ParseScope(token.Scope.String))
+	assert.True(t, token.HasScopes("products:write"))
+	assert.True(t, token.HasScopes("brands:write", "products:write"))
+	assert.False(t, token.HasScopes("products:write", "users:write"))
+	assert.False(t, new(oauth.OauthAccessToken).HasScopes("products:write"))
+}
@@ -0,0 +1,309 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// TokenFormat selects how access tokens are issued.
+type TokenFormat string
+
+const (
+	// Opaque access tokens are random strings stored in oauth_access_tokens.
+	Opaque TokenFormat = "opaque"
+	// JWT access tokens are signed by a KeySet and validated without a lookup.
+	JWT TokenFormat = "jwt"
+)
+
+const (
+	algorithmES256 = "ES256"
+	algorithmRS256 = "RS256"
+
+	// jwtType is the JWT type of access tokens defined in RFC 9068.
+	jwtType = "at+jwt"
+)
+
+// SigningKey is a private key used to sign JWT access tokens. Its ID is the
+// RFC 7638 thumbprint of the public key.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+// ParseSigningKeyPEM reads a PEM encoded P-256 ECDSA key, used with ES256, or
+// RSA key, used with RS256.
+func ParseSigningKeyPEM(data []byte) (key SigningKey, err error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		err = errors.New("no PEM block found")
+		return
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return
+	}
+
+	switch k := parsed.(type) {
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			err = errors.New("only P-256 ECDSA keys are supported")
+			return
+		}
+		key = SigningKey{Algorithm: algorithmES256, PrivateKey: k}
+	case *rsa.PrivateKey:
+		key = SigningKey{Algorithm: algorithmRS256, PrivateKey: k}
+	default:
+		err = fmt.Errorf("unsupported key type %T", parsed)
+		return
+	}
+
+	key.ID, err = key.publicJWK().thumbprint()
+	return
+}
+
+func (k SigningKey) sign(signingInput []byte) ([]byte, error) {
+	digest := sha256.Sum256(signingInput)
+
+	switch key := k.PrivateKey.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	}
+
+	return nil, fmt.Errorf("unsupported key type %T", k.PrivateKey)
+}
+
+func (k SigningKey) verify(signingInput []byte, signature []byte) bool {
+	digest := sha256.Sum256(signingInput)
+
+	switch key := k.PrivateKey.Public().(type) {
+	case *ecdsa.PublicKey:
+		if len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	return false
+}
+
+// JSONWebKey is the public part of a SigningKey as described in RFC 7517.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is the document served by the JWKS endpoint.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+func (k SigningKey) publicJWK() JSONWebKey {
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch key := k.PrivateKey.Public().(type) {
+	case *ecdsa.PublicKey:
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		return JSONWebKey{KeyType: "EC", Curve: "P-256", X: encode(x), Y: encode(y)}
+	case *rsa.PublicKey:
+		return JSONWebKey{KeyType: "RSA", N: encode(key.N.Bytes()), E: encode(big.NewInt(int64(key.E)).Bytes())}
+	}
+
+	return JSONWebKey{}
+}
+
+// thumbprint computes the RFC 7638 thumbprint of a public key.
+func (j JSONWebKey) thumbprint() (string, error) {
+	var members string
+	switch j.KeyType {
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, j.Curve, j.X, j.Y)
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, j.E, j.N)
+	default:
+		return "", fmt.Errorf("unsupported key type %q", j.KeyType)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// KeySet holds the keys JWT access tokens are signed with. The first key signs
+// new tokens and every key verifies, so keys are rotated by prepending the new
+// key and dropping the old one once its tokens have expired.
+type KeySet struct {
+	keys []SigningKey
+}
+
+func NewKeySet(keys ...SigningKey) *KeySet {
+	return &KeySet{
+		keys: keys,
+	}
+}
+
+// JWKS returns the public keys of the set.
+func (k *KeySet) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range k.keys {
+		jwk := key.publicJWK()
+		jwk.KeyID = key.ID
+		jwk.Use = "sig"
+		jwk.Algorithm = key.Algorithm
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Claims are the claims of a JWT access token. Subject is the user ID and is
+// empty for client credentials tokens.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	ClientID  string `json:"client_id"`
+	Scope     string `json:"scope,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat"`
+	ID        string `json:"jti"`
+}
+
+// Sign returns the compact JWS serialization of claims, signed with the
+// current key.
+func (k *KeySet) Sign(claims Claims) (string, error) {
+	if len(k.keys) == 0 {
+		return "", errors.New("no signing key configured")
+	}
+	key := k.keys[0]
+
+	header, err := json.Marshal(jwtHeader{Algorithm: key.Algorithm, Type: jwtType, KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encode := base64.RawURLEncoding.EncodeToString
+	signingInput := encode(header) + "." + encode(payload)
+
+	signature, err := key.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encode(signature), nil
+}
+
+// Verify checks the signature, type and expiry of a JWT access token and
+// returns its claims.
+func (k *KeySet) Verify(token string) (claims Claims, err error) {
+	invalid := errors.New(ErrorInvalidToken)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, invalid
+	}
+
+	decode := base64.RawURLEncoding.DecodeString
+	rawHeader, err := decode(parts[0])
+	if err != nil {
+		return claims, invalid
+	}
+	rawPayload, err := decode(parts[1])
+	if err != nil {
+		return claims, invalid
+	}
+	signature, err := decode(parts[2])
+	if err != nil {
+		return claims, invalid
+	}
+
+	var header jwtHeader
+	if err = json.Unmarshal(rawHeader, &header); err != nil || header.Type != jwtType {
+		return claims, invalid
+	}
+
+	key, ok := k.key(header.KeyID)
+	// The algorithm is taken from the key, never from the token alone.
+	if !ok || header.Algorithm != key.Algorithm {
+		return claims, invalid
+	}
+	if !key.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return claims, invalid
+	}
+
+	if err = json.Unmarshal(rawPayload, &claims); err != nil {
+		return claims, invalid
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return claims, errors.New("token expired")
+	}
+
+	return claims, nil
+}
+
+func (k *KeySet) key(id string) (SigningKey, bool) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+// isJWT reports whether an access token is in the compact JWS serialization
+// rather than an opaque token.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
@@ -0,0 +1,83 @@
+package oauth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySet(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	assert.NoError(t, err)
+	es256, err := oauth.ParseSigningKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}))
+	assert.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rs256, err := oauth.ParseSigningKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	assert.NoError(t, err)
+
+	claims := oauth.Claims{
+		Subject:   "7b0a8a4e-0f4c-4c8e-9d6e-1b1f2a3c4d5e",
+		ClientID:  "client_web",
+		Scope:     "products:write",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		IssuedAt:  time.Now().Unix(),
+		ID:        "jti",
+	}
+
+	for _, key := range []oauth.SigningKey{es256, rs256} {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			keySet := oauth.NewKeySet(key)
+
+			token, err := keySet.Sign(claims)
+			assert.NoError(t, err)
+
+			got, err := keySet.Verify(token)
+			assert.NoError(t, err)
+			assert.Equal(t, claims, got)
+
+			parts := strings.Split(token, ".")
+			_, err = keySet.Verify(parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])))
+			assert.Error(t, err)
+
+			jwks := keySet.JWKS()
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, key.ID, jwks.Keys[0].KeyID)
+			assert.Equal(t, key.Algorithm, jwks.Keys[0].Algorithm)
+		})
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		old, err := oauth.NewKeySet(rs256).Sign(claims)
+		assert.NoError(t, err)
+
+		_, err = oauth.NewKeySet(es256, rs256).Verify(old)
+		assert.NoError(t, err)
+
+		_, err = oauth.NewKeySet(es256).Verify(old)
+		assert.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := claims
+		expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+
+		token, err := oauth.NewKeySet(es256).Sign(expired)
+		assert.NoError(t, err)
+
+		_, err = oauth.NewKeySet(es256).Verify(token)
+		assert.Error(t, err)
+	})
+}
@@ -1,11 +1,12 @@
 package oauth
 
 import (
-	"golang.org/x/crypto/bcrypt"
-	"strconv"
+	"crypto/subtle"
+	"strings"
 	"time"
 
 	"github.com/guregu/null"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type TokenType string
@@ -14,19 +15,41 @@ const (
 	Bearer TokenType = "Bearer"
 )
 
-var scope = struct {
-	User string
-}{
-	"user",
+// ParseScope splits a space delimited scope, as used in requests and in the
+// scope columns, into its scope tokens.
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// scopeCovers reports whether every scope token of requested is in granted.
+func scopeCovers(granted string, requested ...string) bool {
+	grantedSet := make(map[string]bool)
+	for _, s := range ParseScope(granted) {
+		grantedSet[s] = true
+	}
+
+	for _, s := range requested {
+		if !grantedSet[s] {
+			return false
+		}
+	}
+	return true
 }
 
-// Credential is
+// Credential is the set of token request parameters described in RFC 6749.
 type Credential struct {
-	GrantType    GrantType
-	ClientID     string
-	ClientSecret string
-	Username     string
-	Password     string
+	GrantType    GrantType `json:"grant_type"`
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret"`
+	Username     string    `json:"username"`
+	Password     string    `json:"password"`
+	RefreshToken string    `json:"refresh_token"`
+	Scope        string    `json:"scope"`
+
+	// Token and TokenTypeHint are the parameters of revocation and
+	// introspection requests.
+	Token         string        `json:"token"`
+	TokenTypeHint TokenTypeHint `json:"token_type_hint"`
 }
 
 type OauthAccessToken struct {
@@ -35,16 +58,14 @@ type OauthAccessToken struct {
 	UserID      null.String `json:"userId" db:"user_id"`
 	Expires     time.Time   `json:"expires" db:"expires"`
 	Scope       null.String `json:"scope" db:"scope"`
-}
 
-func (o *OauthAccessToken) Generate(accessToken string, clientID string, userID *int, withScope bool, config Config) OauthAccessToken {
-	if userID != nil {
-		o.UserID = null.StringFrom(strconv.Itoa(*userID))
-	}
+	// RefreshToken is set on access tokens issued together with a refresh token.
+	RefreshToken string `json:"-" db:"-"`
+}
 
-	if withScope {
-		o.Scope = null.StringFrom(scope.User)
-	}
+func (o *OauthAccessToken) Generate(accessToken string, clientID string, userID null.String, scope null.String, config Config) OauthAccessToken {
+	o.UserID = userID
+	o.Scope = scope
 
 	o.ClientID = clientID
 	o.AccessToken = accessToken
@@ -63,49 +84,131 @@ func (o *OauthAccessToken) VerifyExpireIn() bool {
 }
 
 func (o *OauthAccessToken) VerifyUserLoggedIn() bool {
-	if o.UserID.Valid && !o.Scope.Valid {
-		return true
-	}
-	return false
+	return o.UserID.Valid
+}
+
+// HasScopes reports whether the token was granted all of scopes.
+func (o *OauthAccessToken) HasScopes(scopes ...string) bool {
+	return scopeCovers(o.Scope.String, scopes...)
 }
 
 func (o *OauthAccessToken) toCreateTokenResponse() *TokenResponse {
 	return &TokenResponse{
-		AccessToken: o.AccessToken,
-		ExpiresIn:   o.Expires,
-		TokenType:   string(Bearer),
-		Scope:       scope.User,
+		AccessToken:  o.AccessToken,
+		TokenType:    string(Bearer),
+		ExpiresIn:    int64(time.Until(o.Expires).Round(time.Second) / time.Second),
+		Scope:        o.Scope.String,
+		RefreshToken: o.RefreshToken,
 	}
 }
 
+type OauthRefreshToken struct {
+	RefreshToken string      `json:"refreshToken" db:"refresh_token"`
+	ClientID     string      `json:"clientId" db:"client_id"`
+	UserID       null.String `json:"userId" db:"user_id"`
+	Expires      time.Time   `json:"expires" db:"expires"`
+	Scope        null.String `json:"scope" db:"scope"`
+}
+
+// Generate creates a refresh token carrying the client, user and scope of
+// accessToken.
+func (o *OauthRefreshToken) Generate(refreshToken string, accessToken OauthAccessToken, config Config) OauthRefreshToken {
+	o.RefreshToken = refreshToken
+	o.ClientID = accessToken.ClientID
+	o.UserID = accessToken.UserID
+	o.Scope = accessToken.Scope
+	o.Expires = time.Now().Add(time.Second * time.Duration(config.RefreshExpiration))
+
+	return *o
+}
+
+func (o *OauthRefreshToken) VerifyExpireIn() bool {
+	return time.Now().Before(o.Expires)
+}
+
 type OauthClient struct {
-	ClientID     string `json:"clientId" db:"client_id"`
-	ClientSecret string `json:"clientSecret" db:"client_secret"`
-	RedirectURI  string `json:"redirectUri" db:"redirect_uri"`
-	GrantTypes   string `json:"grantTypes" db:"grant_types"`
+	ClientID     string      `json:"clientId" db:"client_id"`
+	ClientSecret string      `json:"clientSecret" db:"client_secret"`
+	RedirectURI  string      `json:"redirectUri" db:"redirect_uri"`
+	GrantTypes   string      `json:"grantTypes" db:"grant_types"`
+	Scope        null.String `json:"scope" db:"scope"`
 }
 
+// VerifyClient checks the client credentials, the secret against its bcrypt
+// hash. Clients whose secret is still stored in plaintext are rejected, see
+// Token.HashClientSecrets.
 func (o *OauthClient) VerifyClient(credential Credential) bool {
-	if o.ClientID != credential.ClientID {
+	if subtle.ConstantTimeCompare([]byte(o.ClientID), []byte(credential.ClientID)) != 1 {
 		return false
 	}
 
-	if o.ClientSecret != credential.ClientSecret {
-		return false
-	}
+	err := bcrypt.CompareHashAndPassword([]byte(o.ClientSecret), []byte(credential.ClientSecret))
+	return err == nil
+}
 
-	return true
+// HasHashedSecret reports whether the stored secret is a bcrypt hash rather
+// than a legacy plaintext secret.
+func (o *OauthClient) HasHashedSecret() bool {
+	_, err := bcrypt.Cost([]byte(o.ClientSecret))
+	return err == nil
+}
+
+// HashClientSecret returns the bcrypt hash to store for a client secret.
+func HashClientSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	return string(hash), err
 }
 
+// ClientRegistration describes a client created through Token.CreateClient.
+type ClientRegistration struct {
+	ClientID    string      `json:"clientId" validate:"required,max=32,printascii"`
+	RedirectURI string      `json:"redirectUri" validate:"omitempty,url,max=1000"`
+	GrantTypes  []GrantType `json:"grantTypes" validate:"required,min=1,dive,oneof=client_credentials password refresh_token"`
+	Scope       string      `json:"scope" validate:"max=2000"`
+}
+
+// ClientCredentialResponse carries a newly generated client secret. It is the
+// only time the plaintext secret is available.
+type ClientCredentialResponse struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+}
+
+// AllowsGrantType reports whether grantType is listed in the space separated
+// grant_types of the client.
+func (o *OauthClient) AllowsGrantType(grantType GrantType) bool {
+	for _, g := range strings.Fields(o.GrantTypes) {
+		if GrantType(g) == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenResponse is the successful access token response of RFC 6749 section
+// 5.1. ExpiresIn is the lifetime of the access token in seconds.
 type TokenResponse struct {
-	AccessToken string
-	ExpiresIn   time.Time
-	TokenType   string
-	Scope       string
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// IntrospectionResponse is the introspection response of RFC 7662 section
+// 2.2. Inactive tokens only carry Active. Exp is a Unix timestamp.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	ClientID  string `json:"client_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
 }
 
+// User is the resource owner of the password grant, stored in the user table.
 type User struct {
-	ID       int    `json:"id" db:"id"`
+	ID       string `json:"id" db:"user_id"`
 	Username string `json:"username" db:"username"`
 	Password string `json:"password" db:"password"`
 }
@@ -3,15 +3,20 @@ package oauth
 import (
 	"errors"
 	"strings"
+	"time"
+
+	"github.com/guregu/null"
 )
 
 type Parser struct {
 	TokenStore TokenStore
+	Config     Config
 }
 
-func NewParser(tokenStore TokenStore) *Parser {
+func NewParser(tokenStore TokenStore, config Config) *Parser {
 	return &Parser{
 		TokenStore: tokenStore,
+		Config:     config,
 	}
 }
 
@@ -29,6 +34,10 @@ func (p *Parser) Parse(accessToken string) (accessTokenClient OauthAccessToken,
 		return
 	}
 
+	if p.Config.Keys != nil && isJWT(token[1]) {
+		return p.parseJWT(token[1])
+	}
+
 	accessTokenClient, err = p.TokenStore.resolveAccessTokenByAccessToken(token[1])
 	if err != nil {
 		return
@@ -37,6 +46,39 @@ func (p *Parser) Parse(accessToken string) (accessTokenClient OauthAccessToken,
 	return
 }
 
+// parseJWT validates a JWT access token without reading oauth_access_tokens.
+// Only the denylist of revoked tokens is consulted, through the token cache
+// when there is one.
+func (p *Parser) parseJWT(token string) (accessTokenClient OauthAccessToken, err error) {
+	claims, err := p.Config.Keys.Verify(token)
+	if err != nil {
+		return
+	}
+
+	if p.Config.Issuer != "" && claims.Issuer != p.Config.Issuer {
+		err = errors.New(ErrorInvalidToken)
+		return
+	}
+
+	revoked, err := p.TokenStore.isRevoked(claims.ID, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return
+	}
+	if revoked {
+		err = errors.New(ErrorRevokedToken)
+		return
+	}
+
+	accessTokenClient = OauthAccessToken{
+		AccessToken: token,
+		ClientID:    claims.ClientID,
+		UserID:      null.NewString(claims.Subject, claims.Subject != ""),
+		Expires:     time.Unix(claims.ExpiresAt, 0),
+		Scope:       null.NewString(claims.Scope, claims.Scope != ""),
+	}
+	return
+}
+
 func (p *Parser) validTokenTypeBearer(tokenType string) bool {
 	if tokenType != string(Bearer) {
 		return false
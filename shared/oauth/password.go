@@ -1,47 +1,50 @@
 package oauth
 
 import (
-	"errors"
+	"github.com/guregu/null"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// unknownUserPassword is checked against the password of unknown users, so
+// that they take as long to reject as wrong passwords.
+var unknownUserPassword, _ = bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost)
+
 type PasswordAuth struct {
 	tokenStore TokenStore
 	config     Config
 }
 
 func (c *PasswordAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
-	client, err := c.tokenStore.resolveClientByClientID(credential.ClientID)
+	client, err := resolveClient(c.tokenStore, credential)
 	if err != nil {
 		return
 	}
 
-	if !client.VerifyClient(credential) {
-		err = errors.New(ErrorInvalidClient)
+	scope, err := grantScope(credential.Scope, client.Scope)
+	if err != nil {
 		return
 	}
 
-	user, err := c.tokenStore.resolveByTelephoneOrEmail(credential.Username)
+	// Unknown users get the error of a wrong password, not to tell which
+	// usernames exist.
+	user, found, err := c.tokenStore.findUserByUsernameOrEmail(credential.Username)
 	if err != nil {
 		return
 	}
-
-	if !user.ValidCredential(credential) {
-		err = errors.New(ErrorInvalidPassword)
-		return
+	if !found {
+		user.Password = string(unknownUserPassword)
 	}
 
-	accessToken, err := generateAccessToken()
-	if err != nil {
-		err = errors.New(ErrorGenerateAccessToken)
+	if !user.ValidCredential(credential) || !found {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidPassword)
 		return
 	}
 
-	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, &user.ID, false, c.config)
-
-	err = c.tokenStore.createAccessToken(oauthAccessToken)
+	oauthAccessToken, err = issueAccessToken(c.tokenStore, c.config, credential.ClientID, null.StringFrom(user.ID), scope)
 	if err != nil {
 		return
 	}
 
+	err = issueRefreshToken(c.tokenStore, &oauthAccessToken, scope, c.config)
 	return
 }
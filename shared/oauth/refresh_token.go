@@ -0,0 +1,55 @@
+package oauth
+
+type RefreshTokenAuth struct {
+	tokenStore TokenStore
+	config     Config
+}
+
+// Create exchanges a refresh token for a new access token. The refresh token
+// is rotated: it is deleted and a new one is issued with the access token.
+func (c *RefreshTokenAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
+	_, err = resolveClient(c.tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	if credential.RefreshToken == "" {
+		err = NewError(ErrorCodeInvalidRequest, ErrorMissingRefreshToken)
+		return
+	}
+
+	refreshToken, err := c.tokenStore.resolveRefreshTokenByRefreshToken(credential.RefreshToken)
+	if err != nil {
+		return
+	}
+
+	if refreshToken.ClientID != credential.ClientID || !refreshToken.VerifyExpireIn() {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidRefreshToken)
+		return
+	}
+
+	// The new access token may narrow, but never widen, the original scope.
+	scope, err := grantScope(credential.Scope, refreshToken.Scope)
+	if err != nil {
+		return
+	}
+
+	// Only the request deleting the refresh token may use it, so a token
+	// replayed concurrently is rejected.
+	deleted, err := c.tokenStore.deleteRefreshToken(refreshToken.RefreshToken)
+	if err != nil {
+		return
+	}
+	if !deleted {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidRefreshToken)
+		return
+	}
+
+	oauthAccessToken, err = issueAccessToken(c.tokenStore, c.config, credential.ClientID, refreshToken.UserID, scope)
+	if err != nil {
+		return
+	}
+
+	err = issueRefreshToken(c.tokenStore, &oauthAccessToken, refreshToken.Scope, c.config)
+	return
+}
@@ -3,6 +3,7 @@ package oauth
 import (
 	"crypto/rand"
 	"crypto/sha512"
+	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
@@ -32,3 +33,17 @@ func generateAccessToken() (string, error) {
 
 	return string(accessToken[0:40]), nil
 }
+
+// generateClientSecret returns a random client secret along with the hash to
+// store for it.
+func generateClientSecret() (secret string, hash string, err error) {
+	b := make([]byte, 24)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+
+	secret = base64.RawURLEncoding.EncodeToString(b)
+	hash, err = HashClientSecret(secret)
+	return
+}
@@ -0,0 +1,111 @@
+package oauth
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	accessTokenCachePrefix  = "oauth:access_token:"
+	revokedTokenCachePrefix = "oauth:revoked_token:"
+)
+
+// TokenCache keeps resolved opaque access tokens, and whether JWTs are revoked,
+// in Redis so authenticating a request does not need a database round trip.
+// Cache failures are logged and treated as misses, the database staying the
+// source of truth.
+type TokenCache struct {
+	client *redis.Client
+	maxTTL time.Duration
+}
+
+// NewTokenCache creates a TokenCache. Entries live until their token expires,
+// or for maxTTL if that is shorter and positive.
+func NewTokenCache(client *redis.Client, maxTTL time.Duration) *TokenCache {
+	return &TokenCache{
+		client: client,
+		maxTTL: maxTTL,
+	}
+}
+
+func (c *TokenCache) get(accessToken string) (oauthAccessToken OauthAccessToken, found bool) {
+	data, err := c.client.Get(accessTokenCachePrefix + accessToken).Bytes()
+	if err == redis.Nil {
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed reading access token cache")
+		return
+	}
+
+	err = json.Unmarshal(data, &oauthAccessToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed decoding cached access token")
+		return
+	}
+
+	return oauthAccessToken, true
+}
+
+func (c *TokenCache) set(oauthAccessToken OauthAccessToken) {
+	ttl := c.ttl(oauthAccessToken.Expires)
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(oauthAccessToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed encoding access token for cache")
+		return
+	}
+
+	err = c.client.Set(accessTokenCachePrefix+oauthAccessToken.AccessToken, data, ttl).Err()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed writing access token cache")
+	}
+}
+
+func (c *TokenCache) delete(accessToken string) error {
+	return c.client.Del(accessTokenCachePrefix + accessToken).Err()
+}
+
+// getRevoked reads whether the JWT of jti is revoked, found being false on a
+// miss.
+func (c *TokenCache) getRevoked(jti string) (revoked bool, found bool) {
+	flag, err := c.client.Get(revokedTokenCachePrefix + jti).Int()
+	if err == redis.Nil {
+		return
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed reading revoked token cache")
+		return
+	}
+
+	return flag == 1, true
+}
+
+// setRevoked keeps whether the JWT of jti is revoked until the token expires,
+// or for maxTTL if that is shorter and positive.
+func (c *TokenCache) setRevoked(jti string, revoked bool, expires time.Time) error {
+	ttl := c.ttl(expires)
+	if ttl <= 0 {
+		return nil
+	}
+
+	flag := 0
+	if revoked {
+		flag = 1
+	}
+	return c.client.Set(revokedTokenCachePrefix+jti, flag, ttl).Err()
+}
+
+func (c *TokenCache) ttl(expires time.Time) time.Duration {
+	ttl := time.Until(expires)
+	if c.maxTTL > 0 && c.maxTTL < ttl {
+		ttl = c.maxTTL
+	}
+	return ttl
+}
@@ -3,12 +3,15 @@ package oauth
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
 )
 
 type TokenStore struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	cache *TokenCache
 }
 
 const (
@@ -35,17 +38,69 @@ const (
 		FROM
 			oauth_access_tokens`
 
+	queryInsertRefreshToken = `INSERT INTO oauth_refresh_tokens (
+			refresh_token,
+			client_id,
+			user_id,
+			expires,
+			scope
+		) VALUES (
+			:refresh_token,
+			:client_id,
+			:user_id,
+			:expires,
+			:scope
+		)`
+
+	querySelectRefreshToken = `SELECT
+			refresh_token,
+			client_id,
+			user_id,
+			expires,
+			scope
+		FROM
+			oauth_refresh_tokens`
+
+	queryDeleteRefreshToken = `DELETE FROM oauth_refresh_tokens WHERE refresh_token = ?`
+
+	queryDeleteAccessTokenOfClient = `DELETE FROM oauth_access_tokens WHERE access_token = ? AND client_id = ?`
+
+	queryDeleteRefreshTokenOfClient = `DELETE FROM oauth_refresh_tokens WHERE refresh_token = ? AND client_id = ?`
+
+	queryInsertRevokedToken = `INSERT IGNORE INTO oauth_revoked_tokens (jti, expires) VALUES (?, ?)`
+
+	queryDeleteExpiredRevokedTokens = `DELETE FROM oauth_revoked_tokens WHERE expires < ?`
+
+	queryCountRevokedToken = `SELECT COUNT(jti) FROM oauth_revoked_tokens WHERE jti = ?`
+
+	queryInsertClient = `INSERT INTO oauth_clients (
+			client_id,
+			client_secret,
+			redirect_uri,
+			grant_types,
+			scope
+		) VALUES (
+			:client_id,
+			:client_secret,
+			:redirect_uri,
+			:grant_types,
+			:scope
+		)`
+
+	queryUpdateClientSecret = `UPDATE oauth_clients SET client_secret = ? WHERE client_id = ?`
+
 	querySelectClients = `SELECT
 			client_id,
 			client_secret,
 			redirect_uri,
-			grant_types
+			grant_types,
+			scope
 		FROM 
 			oauth_clients`
 
 	querySelectUser = `
 			SELECT
-				id,
+				user_id,
 				username,
 				password
 			FROM
@@ -73,7 +128,19 @@ func (a *TokenStore) createAccessToken(accessToken OauthAccessToken) error {
 	return nil
 }
 
+// WithCache returns a TokenStore resolving access tokens through cache first.
+func (a TokenStore) WithCache(cache *TokenCache) TokenStore {
+	a.cache = cache
+	return a
+}
+
 func (a *TokenStore) resolveAccessTokenByAccessToken(accessToken string) (oauthAccessToken OauthAccessToken, err error) {
+	if a.cache != nil {
+		if cached, found := a.cache.get(accessToken); found {
+			return cached, nil
+		}
+	}
+
 	err = a.db.Get(&oauthAccessToken, querySelectAccessToken+" WHERE access_token = ?", accessToken)
 	switch {
 	case err == sql.ErrNoRows:
@@ -83,13 +150,106 @@ func (a *TokenStore) resolveAccessTokenByAccessToken(accessToken string) (oauthA
 		return
 	}
 
+	if a.cache != nil {
+		a.cache.set(oauthAccessToken)
+	}
+
+	return
+}
+
+func (a *TokenStore) createRefreshToken(refreshToken OauthRefreshToken) error {
+	stmt, err := a.db.PrepareNamed(queryInsertRefreshToken)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *TokenStore) resolveRefreshTokenByRefreshToken(refreshToken string) (oauthRefreshToken OauthRefreshToken, err error) {
+	err = a.db.Get(&oauthRefreshToken, querySelectRefreshToken+" WHERE refresh_token = ?", refreshToken)
+	switch {
+	case err == sql.ErrNoRows:
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidRefreshToken)
+		return
+	case err != nil:
+		return
+	}
+
+	return
+}
+
+// deleteRefreshToken reports whether this call actually deleted the token.
+func (a *TokenStore) deleteRefreshToken(refreshToken string) (deleted bool, err error) {
+	return a.execAffectingRows(queryDeleteRefreshToken, refreshToken)
+}
+
+// findAccessToken is like resolveAccessTokenByAccessToken but reports a
+// missing token through found instead of an error.
+func (a *TokenStore) findAccessToken(accessToken string) (oauthAccessToken OauthAccessToken, found bool, err error) {
+	err = a.db.Get(&oauthAccessToken, querySelectAccessToken+" WHERE access_token = ?", accessToken)
+	switch {
+	case err == sql.ErrNoRows:
+		return oauthAccessToken, false, nil
+	case err != nil:
+		return
+	}
+
+	return oauthAccessToken, true, nil
+}
+
+func (a *TokenStore) findRefreshToken(refreshToken string) (oauthRefreshToken OauthRefreshToken, found bool, err error) {
+	err = a.db.Get(&oauthRefreshToken, querySelectRefreshToken+" WHERE refresh_token = ?", refreshToken)
+	switch {
+	case err == sql.ErrNoRows:
+		return oauthRefreshToken, false, nil
+	case err != nil:
+		return
+	}
+
+	return oauthRefreshToken, true, nil
+}
+
+func (a *TokenStore) deleteAccessTokenOfClient(accessToken string, clientID string) (deleted bool, err error) {
+	deleted, err = a.execAffectingRows(queryDeleteAccessTokenOfClient, accessToken, clientID)
+	if err != nil || !deleted || a.cache == nil {
+		return
+	}
+
+	// A revoked token must not outlive its row in the cache.
+	err = a.cache.delete(accessToken)
 	return
 }
 
-func (a *TokenStore) resolveAllClients(db *sqlx.DB) ([]OauthClient, error) {
+func (a *TokenStore) deleteRefreshTokenOfClient(refreshToken string, clientID string) (deleted bool, err error) {
+	return a.execAffectingRows(queryDeleteRefreshTokenOfClient, refreshToken, clientID)
+}
+
+// execAffectingRows runs a write query and reports whether it changed any row.
+func (a *TokenStore) execAffectingRows(query string, args ...interface{}) (changed bool, err error) {
+	res, err := a.db.Exec(query, args...)
+	if err != nil {
+		return
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affected > 0, nil
+}
+
+func (a *TokenStore) resolveAllClients() ([]OauthClient, error) {
 	var clients []OauthClient
 
-	err := a.db.Get(&clients, querySelectClients)
+	err := a.db.Select(&clients, querySelectClients)
 	if err != nil {
 		return []OauthClient{}, err
 	}
@@ -101,7 +261,7 @@ func (a *TokenStore) resolveClientByClientID(clientID string) (client OauthClien
 	err = a.db.Get(&client, querySelectClients+" WHERE client_id = ?", clientID)
 	switch {
 	case err == sql.ErrNoRows:
-		err = errors.New(ErrorClientNotFound)
+		err = NewError(ErrorCodeInvalidClient, ErrorClientNotFound)
 		return
 	case err != nil:
 		return
@@ -110,16 +270,71 @@ func (a *TokenStore) resolveClientByClientID(clientID string) (client OauthClien
 	return
 }
 
-func (a *TokenStore) resolveByTelephoneOrEmail(username string) (User, error) {
-	var user User
+// createRevokedToken denylists a JWT until it expires. Entries past their
+// expiry are pruned on the way, as their tokens are rejected anyway.
+func (a *TokenStore) createRevokedToken(jti string, expires time.Time) error {
+	_, err := a.db.Exec(queryInsertRevokedToken, jti, expires)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.Exec(queryDeleteExpiredRevokedTokens, time.Now())
+	if err != nil || a.cache == nil {
+		return err
+	}
+
+	// The token may be cached as not revoked.
+	return a.cache.setRevoked(jti, true, expires)
+}
+
+// isRevoked reads the denylist through the cache, if any, only querying
+// oauth_revoked_tokens on a miss. Both outcomes are cached until the token
+// expires.
+func (a *TokenStore) isRevoked(jti string, expires time.Time) (revoked bool, err error) {
+	if a.cache != nil {
+		if cached, found := a.cache.getRevoked(jti); found {
+			return cached, nil
+		}
+	}
+
+	err = a.db.Get(&revoked, queryCountRevokedToken, jti)
+	if err != nil {
+		return
+	}
+
+	if a.cache != nil {
+		if err := a.cache.setRevoked(jti, revoked, expires); err != nil {
+			log.Warn().Err(err).Msg("Failed writing revoked token cache")
+		}
+	}
+	return
+}
+
+func (a *TokenStore) createClient(client OauthClient) error {
+	stmt, err := a.db.PrepareNamed(queryInsertClient)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(client)
+	return err
+}
+
+func (a *TokenStore) updateClientSecret(clientID string, clientSecret string) (updated bool, err error) {
+	return a.execAffectingRows(queryUpdateClientSecret, clientSecret, clientID)
+}
 
-	err := a.db.Get(&user, querySelectUser+" WHERE telephone = ? OR  email = ?", username, username)
+// findUserByUsernameOrEmail resolves the active user of a username or email,
+// reporting an unknown one through found instead of an error.
+func (a *TokenStore) findUserByUsernameOrEmail(username string) (user User, found bool, err error) {
+	err = a.db.Get(&user, querySelectUser+" WHERE (username = ? OR email = ?) AND deleted_at IS NULL", username, username)
 	switch {
 	case err == sql.ErrNoRows:
-		return User{}, errors.New(ErrorClientNotFound)
+		return User{}, false, nil
 	case err != nil:
-		return User{}, err
+		return
 	}
 
-	return user, nil
+	return user, true, nil
 }
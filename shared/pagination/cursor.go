@@ -0,0 +1,140 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+)
+
+// Cursor marks a position in a list, for keyset pagination. Sort is the order
+// of the list as read by Fields.ParseSort, and Values the values of the
+// position's row for every field of that order followed by its tie breaker.
+// Before selects the page preceding the position instead of the one following
+// it.
+type Cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+	Before bool     `json:"b,omitempty"`
+}
+
+// CursorQuery is a keyset pagination request. Cursor is nil for the first
+// page.
+type CursorQuery struct {
+	Limit  int
+	Sort   Sort
+	Filter Filter
+	Cursor *Cursor
+}
+
+// NewCursorQuery creates a CursorQuery. When following a cursor, sort must be
+// parsed from the cursor rather than the request so that the order it was
+// created with is kept.
+func NewCursorQuery(limit int, sort Sort, filter Filter, cursor *Cursor) CursorQuery {
+	return CursorQuery{Limit: limit, Sort: sort, Filter: filter, Cursor: cursor}
+}
+
+// Backward reports whether the query walks the list backwards, i.e. fetches
+// the page preceding its cursor.
+func (q CursorQuery) Backward() bool {
+	return q.Cursor != nil && q.Cursor.Before
+}
+
+// CursorPage holds the cursors of the pages around a fetched page. A cursor is
+// nil when there is no page in its direction.
+type CursorPage struct {
+	Next *Cursor
+	Prev *Cursor
+}
+
+// NewCursorPage computes the cursors around a page fetched for q. first and
+// last are the values of the first and last items of the page, in list order,
+// and hasMore reports whether more items were found past the page in the
+// direction q walks.
+func NewCursorPage(q CursorQuery, first, last []string, hasMore bool) (page CursorPage) {
+	sort := q.Sort.String()
+	firstCursor := Cursor{Sort: sort, Values: first, Before: true}
+	lastCursor := Cursor{Sort: sort, Values: last}
+
+	if q.Backward() {
+		page.Next = &lastCursor
+		if hasMore {
+			page.Prev = &firstCursor
+		}
+		return
+	}
+
+	if hasMore {
+		page.Next = &lastCursor
+	}
+	if q.Cursor != nil {
+		page.Prev = &firstCursor
+	}
+	return
+}
+
+// CursorCodec turns cursors into strings of base64 encoded JSON signed with
+// HMAC-SHA256. Clients can decode and read them, but not alter or forge them,
+// so cursors must not carry anything the caller may not see.
+type CursorCodec struct {
+	secret []byte
+}
+
+// NewCursorCodec creates a CursorCodec. Without a secret a random one is
+// generated, in which case cursors do not survive a restart and are not
+// shared between instances.
+func NewCursorCodec(secret string) *CursorCodec {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &CursorCodec{secret: key}
+}
+
+// Encode returns the signed string form of cursor.
+func (c *CursorCodec) Encode(cursor Cursor) string {
+	payload, _ := json.Marshal(cursor)
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode(payload) + "." + encode(c.sign(payload))
+}
+
+// Decode parses a string made by Encode. Malformed or tampered cursors are
+// reported as bad requests.
+func (c *CursorCodec) Decode(s string) (cursor Cursor, err error) {
+	invalid := failure.BadRequestFromString("invalid cursor")
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 {
+		return cursor, invalid
+	}
+
+	decode := base64.RawURLEncoding.DecodeString
+	payload, err := decode(parts[0])
+	if err != nil {
+		return cursor, invalid
+	}
+	signature, err := decode(parts[1])
+	if err != nil {
+		return cursor, invalid
+	}
+
+	if !hmac.Equal(signature, c.sign(payload)) {
+		return cursor, invalid
+	}
+	if err = json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, invalid
+	}
+
+	return cursor, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
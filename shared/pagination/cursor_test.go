@@ -0,0 +1,57 @@
+package pagination_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := pagination.NewCursorCodec("secret")
+	cursor := pagination.Cursor{Sort: "-productName", Values: []string{"Shirt", "42"}, Before: true}
+
+	decoded, err := codec.Decode(codec.Encode(cursor))
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	t.Run("rejects cursors signed with another secret", func(t *testing.T) {
+		_, err := pagination.NewCursorCodec("other").Decode(codec.Encode(cursor))
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+
+	t.Run("rejects malformed cursors", func(t *testing.T) {
+		_, err := codec.Decode("not-a-cursor")
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+}
+
+func TestNewCursorPage(t *testing.T) {
+	sort, err := fields.ParseSort("-productName")
+	assert.NoError(t, err)
+	first := []string{"c", "1"}
+	last := []string{"a", "3"}
+
+	t.Run("first page", func(t *testing.T) {
+		q := pagination.NewCursorQuery(3, sort, pagination.Filter{}, nil)
+		page := pagination.NewCursorPage(q, first, last, true)
+		assert.Nil(t, page.Prev)
+		assert.Equal(t, &pagination.Cursor{Sort: "-productName", Values: last}, page.Next)
+	})
+
+	t.Run("last page walking forward", func(t *testing.T) {
+		q := pagination.NewCursorQuery(3, sort, pagination.Filter{}, &pagination.Cursor{})
+		page := pagination.NewCursorPage(q, first, last, false)
+		assert.Nil(t, page.Next)
+		assert.Equal(t, &pagination.Cursor{Sort: "-productName", Values: first, Before: true}, page.Prev)
+	})
+
+	t.Run("first page walking backward", func(t *testing.T) {
+		q := pagination.NewCursorQuery(3, sort, pagination.Filter{}, &pagination.Cursor{Before: true})
+		page := pagination.NewCursorPage(q, first, last, false)
+		assert.Nil(t, page.Prev)
+		assert.NotNil(t, page.Next)
+	})
+}
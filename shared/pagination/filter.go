@@ -0,0 +1,156 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// Deleted selects how soft deleted items are listed.
+type Deleted string
+
+const (
+	// DeletedExclude leaves soft deleted items out, which is the default.
+	DeletedExclude Deleted = "exclude"
+	// DeletedInclude lists soft deleted items along with the others.
+	DeletedInclude Deleted = "include"
+	// DeletedOnly lists soft deleted items only.
+	DeletedOnly Deleted = "only"
+)
+
+// TimeRange bounds a timestamp. From is inclusive and To exclusive, and
+// either may be left open.
+type TimeRange struct {
+	From null.Time
+	To   null.Time
+}
+
+// NumberRange bounds a number, both ends being inclusive. Either may be left
+// open.
+type NumberRange struct {
+	Min null.Float
+	Max null.Float
+}
+
+// Filter holds the filters of a list request. Every resource applies the ones
+// that make sense for it.
+type Filter struct {
+	BrandID nuuid.NUUID
+	UserID  nuuid.NUUID
+	Deleted Deleted
+	Name    string
+	Status  string
+	Created TimeRange
+	Updated TimeRange
+	Price   NumberRange
+}
+
+// ParseFilterQuery reads the filter query parameters of a list request:
+// brandId, userId, deleted, name, status, createdFrom, createdTo, updatedFrom,
+// updatedTo, priceMin and priceMax. Timestamps are RFC 3339 or plain dates, a
+// plain date used as the end of a range covering the whole day.
+func ParseFilterQuery(r *http.Request) (f Filter, err error) {
+	query := r.URL.Query()
+
+	if f.BrandID, err = parseUUIDQuery(query.Get("brandId"), "brandId"); err != nil {
+		return
+	}
+	if f.UserID, err = parseUUIDQuery(query.Get("userId"), "userId"); err != nil {
+		return
+	}
+
+	f.Deleted = Deleted(strings.ToLower(query.Get("deleted")))
+	switch f.Deleted {
+	case "":
+		f.Deleted = DeletedExclude
+	case DeletedExclude, DeletedInclude, DeletedOnly:
+	default:
+		err = failure.BadRequestFromString("deleted must be one of exclude, include or only")
+		return
+	}
+
+	f.Name = strings.TrimSpace(query.Get("name"))
+	f.Status = query.Get("status")
+
+	if f.Created, err = parseTimeRangeQuery(query.Get("createdFrom"), query.Get("createdTo"), "created"); err != nil {
+		return
+	}
+	if f.Updated, err = parseTimeRangeQuery(query.Get("updatedFrom"), query.Get("updatedTo"), "updated"); err != nil {
+		return
+	}
+
+	if f.Price.Min, err = parseFloatQuery(query.Get("priceMin"), "priceMin"); err != nil {
+		return
+	}
+	if f.Price.Max, err = parseFloatQuery(query.Get("priceMax"), "priceMax"); err != nil {
+		return
+	}
+	if f.Price.Min.Valid && f.Price.Max.Valid && f.Price.Min.Float64 > f.Price.Max.Float64 {
+		err = failure.BadRequestFromString("priceMin must not be greater than priceMax")
+	}
+	return
+}
+
+func parseUUIDQuery(value, key string) (id nuuid.NUUID, err error) {
+	if value == "" {
+		return
+	}
+	parsed, err := uuid.FromString(value)
+	if err != nil {
+		err = failure.BadRequestFromString(fmt.Sprintf("%s must be a UUID", key))
+		return
+	}
+	return nuuid.From(parsed), nil
+}
+
+func parseFloatQuery(value, key string) (f null.Float, err error) {
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		err = failure.BadRequestFromString(fmt.Sprintf("%s must be a number", key))
+		return
+	}
+	return null.FloatFrom(parsed), nil
+}
+
+func parseTimeRangeQuery(from, to, key string) (tr TimeRange, err error) {
+	if from != "" {
+		t, _, parseErr := parseTimeQuery(from)
+		if parseErr != nil {
+			err = failure.BadRequestFromString(fmt.Sprintf("%sFrom must be a date or an RFC 3339 timestamp", key))
+			return
+		}
+		tr.From = null.TimeFrom(t)
+	}
+
+	if to != "" {
+		t, dateOnly, parseErr := parseTimeQuery(to)
+		if parseErr != nil {
+			err = failure.BadRequestFromString(fmt.Sprintf("%sTo must be a date or an RFC 3339 timestamp", key))
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		tr.To = null.TimeFrom(t)
+	}
+	return
+}
+
+func parseTimeQuery(value string) (t time.Time, dateOnly bool, err error) {
+	t, err = time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t.UTC(), false, nil
+	}
+	t, err = time.Parse("2006-01-02", value)
+	return t, true, err
+}
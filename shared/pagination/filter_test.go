@@ -0,0 +1,40 @@
+package pagination_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilterQuery(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/products?brandId=6ba7b810-9dad-11d1-80b4-00c04fd430c8&deleted=only&createdFrom=2021-01-01&createdTo=2021-01-31&priceMin=10&name=+shirt+", nil)
+
+	f, err := pagination.ParseFilterQuery(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", f.BrandID.UUID.String())
+	assert.False(t, f.UserID.Valid)
+	assert.Equal(t, pagination.DeletedOnly, f.Deleted)
+	assert.Equal(t, "shirt", f.Name)
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), f.Created.From.Time)
+	assert.Equal(t, time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), f.Created.To.Time)
+	assert.Equal(t, 10.0, f.Price.Min.Float64)
+	assert.False(t, f.Price.Max.Valid)
+
+	t.Run("excludes deleted items by default", func(t *testing.T) {
+		f, err := pagination.ParseFilterQuery(httptest.NewRequest(http.MethodGet, "/v1/products", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, pagination.DeletedExclude, f.Deleted)
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		for _, query := range []string{"brandId=1", "deleted=maybe", "createdFrom=yesterday", "priceMin=5&priceMax=1"} {
+			_, err := pagination.ParseFilterQuery(httptest.NewRequest(http.MethodGet, "/v1/products?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, failure.GetCode(err), query)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package pagination
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Meta describes the page returned by a paginated list. Page is left out of
+// keyset paginated lists.
+type Meta struct {
+	Page       int `json:"page,omitempty"`
+	Limit      int `json:"limit"`
+	TotalItems int `json:"totalItems"`
+	TotalPages int `json:"totalPages"`
+}
+
+// NewMeta computes the Meta of a page of limit items out of totalItems.
+func NewMeta(page, limit, totalItems int) Meta {
+	meta := Meta{Page: page, Limit: limit, TotalItems: totalItems}
+	if limit > 0 {
+		meta.TotalPages = (totalItems + limit - 1) / limit
+	}
+	return meta
+}
+
+// Links are the URLs of a page of a paginated list and of the pages around
+// it. Links to pages that do not exist are left empty.
+type Links struct {
+	Self  string `json:"self"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// NewOffsetLinks builds the Links of an offset paginated list by changing the
+// page parameter of the request's URL.
+func NewOffsetLinks(r *http.Request, meta Meta) Links {
+	last := meta.TotalPages
+	if last < 1 {
+		last = 1
+	}
+
+	links := Links{
+		Self:  r.URL.RequestURI(),
+		First: withQuery(r, "page", "1"),
+		Last:  withQuery(r, "page", strconv.Itoa(last)),
+	}
+	if meta.Page > 1 {
+		prev := meta.Page - 1
+		if prev > last {
+			prev = last
+		}
+		links.Prev = withQuery(r, "page", strconv.Itoa(prev))
+	}
+	if meta.Page < meta.TotalPages {
+		links.Next = withQuery(r, "page", strconv.Itoa(meta.Page+1))
+	}
+	return links
+}
+
+// NewCursorLinks builds the Links of a keyset paginated list by changing the
+// cursor parameter of the request's URL. There is no link to the last page,
+// which cannot be addressed by a cursor.
+func NewCursorLinks(r *http.Request, nextCursor, prevCursor string) Links {
+	links := Links{
+		Self:  r.URL.RequestURI(),
+		First: withQuery(r, "cursor", ""),
+	}
+	if prevCursor != "" {
+		links.Prev = withQuery(r, "cursor", prevCursor)
+	}
+	if nextCursor != "" {
+		links.Next = withQuery(r, "cursor", nextCursor)
+	}
+	return links
+}
+
+// withQuery returns the request's URL with the key query parameter set to
+// value, or removed when value is empty.
+func withQuery(r *http.Request, key, value string) string {
+	query := r.URL.Query()
+	if value == "" {
+		query.Del(key)
+	} else {
+		query.Set(key, value)
+	}
+
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return u.RequestURI()
+}
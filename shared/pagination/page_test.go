@@ -0,0 +1,42 @@
+package pagination_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOffsetLinks(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/brands?limit=10&page=2&sort=desc", nil)
+	meta := pagination.NewMeta(2, 10, 35)
+	assert.Equal(t, 4, meta.TotalPages)
+
+	links := pagination.NewOffsetLinks(r, meta)
+	assert.Equal(t, pagination.Links{
+		Self:  "/v1/brands?limit=10&page=2&sort=desc",
+		First: "/v1/brands?limit=10&page=1&sort=desc",
+		Prev:  "/v1/brands?limit=10&page=1&sort=desc",
+		Next:  "/v1/brands?limit=10&page=3&sort=desc",
+		Last:  "/v1/brands?limit=10&page=4&sort=desc",
+	}, links)
+
+	t.Run("empty list", func(t *testing.T) {
+		links := pagination.NewOffsetLinks(r, pagination.NewMeta(1, 10, 0))
+		assert.Empty(t, links.Prev)
+		assert.Empty(t, links.Next)
+		assert.Equal(t, links.First, links.Last)
+	})
+}
+
+func TestNewCursorLinks(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/products?cursor=abc&limit=10", nil)
+
+	links := pagination.NewCursorLinks(r, "def", "")
+	assert.Equal(t, "/v1/products?limit=10", links.First)
+	assert.Equal(t, "/v1/products?cursor=def&limit=10", links.Next)
+	assert.Empty(t, links.Prev)
+	assert.Empty(t, links.Last)
+}
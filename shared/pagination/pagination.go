@@ -6,12 +6,14 @@ type Pagination struct {
 	Page   int    `validate:"required"`
 	Limit  int    `validate:"required"`
 	Offset int    `db:"offset"`
-	Field  string `db:"field"`
 	Sort   string `db:"sort"`
+	// Order is set instead of Sort by lists sorted by whitelisted fields.
+	Order  Sort
+	Filter Filter
 }
 
-func NewPaginationQuery(page, limit int, field, sort string) Pagination {
-	pg := Pagination{Page: page, Limit: limit, Offset: (page - 1) * limit, Field: field, Sort: sort}
+func NewPaginationQuery(page, limit int, sort string) Pagination {
+	pg := Pagination{Page: page, Limit: limit, Offset: (page - 1) * limit, Sort: sort}
 	return pg
 }
 
@@ -25,12 +27,3 @@ func GetSortDirection(s string) string {
 		return "ASC"
 	}
 }
-
-func CheckFieldQuery(s string, def string) string {
-	switch strings.ToLower(s) {
-	case "":
-		return def
-	default:
-		return s
-	}
-}
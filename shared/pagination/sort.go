@@ -0,0 +1,180 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+)
+
+// Fields maps the public names of the fields of a resource to their columns.
+// Lists can only be sorted or filtered by the fields listed, which keeps
+// request parameters out of the SQL text.
+type Fields map[string]string
+
+// Column returns the column of a field. Columns are accepted as field names as
+// well, for requests written before fields had public names.
+func (f Fields) Column(name string) (column string, err error) {
+	_, column, err = f.lookup(name)
+	return
+}
+
+func (f Fields) lookup(name string) (publicName string, column string, err error) {
+	if column, ok := f[name]; ok {
+		return name, column, nil
+	}
+	for publicName, column := range f {
+		if column == name {
+			return publicName, column, nil
+		}
+	}
+	return "", "", failure.BadRequestFromString(fmt.Sprintf("unknown field %s", name))
+}
+
+// ParseSort parses a comma separated list of fields, each prefixed with - to
+// sort it in descending order, e.g. "-createdAt,productName".
+func (f Fields) ParseSort(s string) (sort Sort, err error) {
+	if s == "" {
+		return
+	}
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		field := SortField{}
+		if strings.HasPrefix(name, "-") {
+			field.Descending = true
+			name = name[1:]
+		}
+
+		field.Name, field.Column, err = f.lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		sort = append(sort, field)
+	}
+	return
+}
+
+// ParseSortQuery reads the sort query parameter of a list request as parsed by
+// Fields.ParseSort, sorting by def when it is missing. The former form, a
+// field parameter together with a sort parameter of asc or desc, is still
+// understood.
+func ParseSortQuery(r *http.Request, fields Fields, def string) (Sort, error) {
+	sort := ParseQueryParams(r, "sort")
+	field := ParseQueryParams(r, "field")
+	if field == "" {
+		field = def
+	}
+
+	switch strings.ToLower(sort) {
+	case "", "asc":
+		return fields.ParseSort(field)
+	case "desc":
+		return fields.ParseSort("-" + field)
+	}
+	return fields.ParseSort(sort)
+}
+
+// SortField is a field a list is sorted by.
+type SortField struct {
+	Name       string
+	Column     string
+	Descending bool
+}
+
+// Sort is the order of a list, from its most to its least significant field.
+type Sort []SortField
+
+// String returns the sort in the form read by Fields.ParseSort.
+func (s Sort) String() string {
+	names := make([]string, 0, len(s))
+	for _, field := range s {
+		if field.Descending {
+			names = append(names, "-"+field.Name)
+		} else {
+			names = append(names, field.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ThenBy returns the sort with column appended in ascending order, unless it
+// is already sorted by. Sorting by a unique column last makes the order
+// stable, which offset and keyset pagination both rely on.
+func (s Sort) ThenBy(column string) Sort {
+	for _, field := range s {
+		if field.Column == column {
+			return s
+		}
+	}
+	return append(s[:len(s):len(s)], SortField{Column: column})
+}
+
+// Reverse returns the sort with the direction of every field flipped.
+func (s Sort) Reverse() Sort {
+	reversed := make(Sort, len(s))
+	for i, field := range s {
+		field.Descending = !field.Descending
+		reversed[i] = field
+	}
+	return reversed
+}
+
+// Qualified returns the sort with its columns prefixed with table, for queries
+// joining tables that share column names.
+func (s Sort) Qualified(table string) Sort {
+	qualified := make(Sort, len(s))
+	for i, field := range s {
+		field.Column = table + "." + field.Column
+		qualified[i] = field
+	}
+	return qualified
+}
+
+// OrderBy renders the sort as an ORDER BY clause.
+func (s Sort) OrderBy() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	columns := make([]string, 0, len(s))
+	for _, field := range s {
+		if field.Descending {
+			columns = append(columns, field.Column+" DESC")
+		} else {
+			columns = append(columns, field.Column+" ASC")
+		}
+	}
+	return " ORDER BY " + strings.Join(columns, ", ")
+}
+
+// After renders the condition selecting the rows coming after the row holding
+// values in the order of the sort, values having one entry per field.
+func (s Sort) After(values []string) (condition string, args []interface{}, err error) {
+	if len(values) != len(s) {
+		err = failure.BadRequestFromString("cursor does not match the sort")
+		return
+	}
+
+	clauses := make([]string, 0, len(s))
+	for i, field := range s {
+		terms := make([]string, 0, i+1)
+		for j, previous := range s[:i] {
+			terms = append(terms, previous.Column+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := " > ?"
+		if field.Descending {
+			op = " < ?"
+		}
+		terms = append(terms, field.Column+op)
+		args = append(args, values[i])
+
+		clauses = append(clauses, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	condition = "(" + strings.Join(clauses, " OR ") + ")"
+	return
+}
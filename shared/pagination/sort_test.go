@@ -0,0 +1,57 @@
+package pagination_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+var fields = pagination.Fields{
+	"productId":   "product_id",
+	"productName": "product_name",
+	"createdAt":   "created_at",
+}
+
+func TestSort(t *testing.T) {
+	sort, err := fields.ParseSort("-createdAt,productName")
+	assert.NoError(t, err)
+	assert.Equal(t, "-createdAt,productName", sort.String())
+	assert.Equal(t, " ORDER BY created_at DESC, product_name ASC, product_id ASC", sort.ThenBy("product_id").OrderBy())
+	assert.Equal(t, " ORDER BY created_at ASC, product_name DESC", sort.Reverse().OrderBy())
+	assert.Equal(t, " ORDER BY product.created_at DESC, product.product_name ASC", sort.Qualified("product").OrderBy())
+
+	condition, args, err := sort.After([]string{"2021-01-01", "Shirt"})
+	assert.NoError(t, err)
+	assert.Equal(t, "((created_at < ?) OR (created_at = ? AND product_name > ?))", condition)
+	assert.Equal(t, []interface{}{"2021-01-01", "2021-01-01", "Shirt"}, args)
+
+	t.Run("rejects unknown fields", func(t *testing.T) {
+		_, err := fields.ParseSort("productName,product_name; DROP TABLE product")
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+
+	t.Run("accepts columns", func(t *testing.T) {
+		sort, err := fields.ParseSort("-product_name")
+		assert.NoError(t, err)
+		assert.Equal(t, "-productName", sort.String())
+	})
+}
+
+func TestParseSortQuery(t *testing.T) {
+	cases := map[string]string{
+		"/products":                              "productId",
+		"/products?sort=desc":                    "-productId",
+		"/products?field=product_name&sort=desc": "-productName",
+		"/products?sort=-createdAt,productName":  "-createdAt,productName",
+	}
+
+	for url, expected := range cases {
+		sort, err := pagination.ParseSortQuery(httptest.NewRequest(http.MethodGet, url, nil), fields, "productId")
+		assert.NoError(t, err, url)
+		assert.Equal(t, expected, sort.String(), url)
+	}
+}
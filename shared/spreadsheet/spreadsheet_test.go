@@ -0,0 +1,55 @@
+package spreadsheet_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/spreadsheet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCSV(t *testing.T) {
+	rows, err := spreadsheet.ReadCSV(strings.NewReader("\ufeffname,price\n\"Shirt, blue\",10\nSocks\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"name", "price"}, {"Shirt, blue", "10"}, {"Socks"}}, rows)
+}
+
+func TestReadXLSX(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Products" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><t>price</t></si><si><r><t>Shirt</t></r><r><t>, blue</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>10.5</v></c></row>
+			<row r="4"><c r="B4" t="inlineStr"><is><t>Socks</t></is></c></row>
+		</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, _ := archive.Create(name)
+		f.Write([]byte(content))
+	}
+	archive.Close()
+
+	rows, err := spreadsheet.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()), spreadsheet.XLSX)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"name", "price"}, {"Shirt, blue", "10.5"}, nil, {"", "Socks"}}, rows)
+
+	_, err = spreadsheet.ReadXLSX(strings.NewReader("not a zip"), 9)
+	assert.Equal(t, spreadsheet.ErrUnsupportedFormat, err)
+}
+
+func TestFormatOf(t *testing.T) {
+	format, err := spreadsheet.FormatOf("catalog.XLSX")
+	assert.NoError(t, err)
+	assert.Equal(t, spreadsheet.XLSX, format)
+
+	_, err = spreadsheet.FormatOf("catalog.xls")
+	assert.Error(t, err)
+}
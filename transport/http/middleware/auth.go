@@ -1,31 +1,72 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/gofrs/uuid"
 )
 
 type Authentication struct {
-	db *infras.MySQLConn
+	db          *infras.MariaDBConn
+	oauthConfig *oauth.Config
 }
 
+type contextKey string
+
 const (
 	HeaderAuthorization = "Authorization"
+
+	// ContextKeyAccessToken is the request context key holding the resolved
+	// oauth.OauthAccessToken of an authenticated request.
+	ContextKeyAccessToken contextKey = "oauthAccessToken"
 )
 
-func ProvideAuthentication(db *infras.MySQLConn) *Authentication {
+func ProvideAuthentication(db *infras.MariaDBConn, oauthConfig *oauth.Config) *Authentication {
 	return &Authentication{
-		db: db,
+		db:          db,
+		oauthConfig: oauthConfig,
+	}
+}
+
+// AccessTokenFromContext returns the access token stored by the
+// Authentication middleware, if any.
+func AccessTokenFromContext(ctx context.Context) (token oauth.OauthAccessToken, ok bool) {
+	token, ok = ctx.Value(ContextKeyAccessToken).(oauth.OauthAccessToken)
+	return
+}
+
+// UserIDFromContext returns the ID of the user owning the access token of the
+// request. Tokens issued without a user, such as client credentials, yield an
+// Unauthorized failure.
+func UserIDFromContext(ctx context.Context) (userID uuid.UUID, err error) {
+	token, ok := AccessTokenFromContext(ctx)
+	if !ok || !token.UserID.Valid {
+		err = failure.Unauthorized("request is not authenticated as a user")
+		return
+	}
+
+	userID, err = uuid.FromString(token.UserID.String)
+	if err != nil {
+		err = failure.Unauthorized("access token has an invalid user")
 	}
+	return
+}
+
+func withAccessToken(r *http.Request, token oauth.OauthAccessToken) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ContextKeyAccessToken, token))
 }
 
 func (a *Authentication) ClientCredential(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		accessToken := r.Header.Get(HeaderAuthorization)
-		token := oauth.New(a.db.Read, oauth.Config{})
+		token := oauth.New(a.db.Read, *a.oauthConfig)
 
 		parseToken, err := token.ParseWithAccessToken(accessToken)
 		if err != nil {
@@ -34,11 +75,11 @@ func (a *Authentication) ClientCredential(next http.Handler) http.Handler {
 		}
 
 		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
+			response.WithMessage(w, http.StatusUnauthorized, "token expired")
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withAccessToken(r, parseToken))
 	})
 }
 
@@ -49,7 +90,7 @@ func (a *Authentication) ClientCredentialWithQueryParameter(next http.Handler) h
 		tokenType := params.Get("token_type")
 		accessToken := tokenType + " " + token
 
-		auth := oauth.New(a.db.Read, oauth.Config{})
+		auth := oauth.New(a.db.Read, *a.oauthConfig)
 		parseToken, err := auth.ParseWithAccessToken(accessToken)
 		if err != nil {
 			response.WithMessage(w, http.StatusUnauthorized, err.Error())
@@ -57,18 +98,18 @@ func (a *Authentication) ClientCredentialWithQueryParameter(next http.Handler) h
 		}
 
 		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
+			response.WithMessage(w, http.StatusUnauthorized, "token expired")
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withAccessToken(r, parseToken))
 	})
 }
 
 func (a *Authentication) Password(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		accessToken := r.Header.Get(HeaderAuthorization)
-		token := oauth.New(a.db.Read, oauth.Config{})
+		token := oauth.New(a.db.Read, *a.oauthConfig)
 
 		parseToken, err := token.ParseWithAccessToken(accessToken)
 		if err != nil {
@@ -86,6 +127,29 @@ func (a *Authentication) Password(next http.Handler) http.Handler {
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withAccessToken(r, parseToken))
 	})
 }
+
+// RequireScopes only lets through requests whose access token was granted all
+// of scopes. It must be mounted after one of the Authentication middlewares.
+func (a *Authentication) RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := AccessTokenFromContext(r.Context())
+			if !ok {
+				response.WithError(w, failure.Unauthorized("request is not authenticated"))
+				return
+			}
+
+			if !token.HasScopes(scopes...) {
+				scope := strings.Join(scopes, " ")
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+				response.WithError(w, failure.Forbidden("access token lacks scope "+scope))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
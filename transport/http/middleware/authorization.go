@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/gofrs/uuid"
+)
+
+// ContextKeyUser is the request context key holding the user.User resolved by
+// the Authorization middleware.
+const ContextKeyUser contextKey = "user"
+
+// OwnerFunc resolves the ID of the user owning the resource a request targets.
+type OwnerFunc func(r *http.Request) (ownerID uuid.UUID, err error)
+
+// Authorization enforces per-route policies on top of Authentication, so it
+// must be mounted after one of the Authentication middlewares.
+type Authorization struct {
+	UserService user.UserService
+}
+
+func ProvideAuthorization(userService user.UserService) *Authorization {
+	return &Authorization{
+		UserService: userService,
+	}
+}
+
+// UserFromContext returns the user stored by the Authorization middleware, if
+// any.
+func UserFromContext(ctx context.Context) (u user.User, ok bool) {
+	u, ok = ctx.Value(ContextKeyUser).(user.User)
+	return
+}
+
+// RequireRole only lets through callers having one of the given roles.
+func (a *Authorization) RequireRole(roles ...user.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, err := a.resolveUser(r)
+			if err != nil {
+				response.WithError(w, err)
+				return
+			}
+
+			if !hasRole(u, roles) {
+				response.WithError(w, failure.Forbidden("role "+u.Role.String()+" is not allowed to perform this action"))
+				return
+			}
+
+			next.ServeHTTP(w, withUser(r, u))
+		})
+	}
+}
+
+// RequireOwnerOrRole lets through callers owning the targeted resource, as
+// resolved by owner, and callers having one of the given roles regardless of
+// ownership.
+func (a *Authorization) RequireOwnerOrRole(owner OwnerFunc, roles ...user.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, err := a.resolveUser(r)
+			if err != nil {
+				response.WithError(w, err)
+				return
+			}
+
+			if !hasRole(u, roles) {
+				ownerID, err := owner(r)
+				if err != nil {
+					response.WithError(w, err)
+					return
+				}
+				if ownerID != u.ID {
+					response.WithError(w, failure.Forbidden("only the owner may perform this action"))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, withUser(r, u))
+		})
+	}
+}
+
+// resolveUser loads the active user owning the access token of the request.
+func (a *Authorization) resolveUser(r *http.Request) (u user.User, err error) {
+	userID, err := UserIDFromContext(r.Context())
+	if err != nil {
+		return
+	}
+
+	u, err = a.UserService.ResolveActiveUser(userID)
+	if failure.GetCode(err) == http.StatusNotFound {
+		err = failure.Unauthorized("access token does not belong to an active user")
+	}
+	return
+}
+
+func hasRole(u user.User, roles []user.Role) bool {
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+func withUser(r *http.Request, u user.User) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ContextKeyUser, u))
+}
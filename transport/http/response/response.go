@@ -6,6 +6,7 @@ import (
 
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/pagination"
 )
 
 // Base is the base object of all responses
@@ -15,6 +16,17 @@ type Base struct {
 	Message *string      `json:"message,omitempty"`
 }
 
+// Paginated is the base object of responses listing a page of items. The
+// cursors are only set by keyset paginated lists, when there is a page in
+// their direction.
+type Paginated struct {
+	Base
+	Meta       pagination.Meta  `json:"meta"`
+	Links      pagination.Links `json:"links"`
+	NextCursor *string          `json:"nextCursor,omitempty"`
+	PrevCursor *string          `json:"prevCursor,omitempty"`
+}
+
 // NoContent sends a response without any content
 func NoContent(w http.ResponseWriter) {
 	respond(w, http.StatusNoContent, nil)
@@ -30,6 +42,24 @@ func WithJSON(w http.ResponseWriter, code int, jsonPayload interface{}) {
 	respond(w, code, Base{Data: &jsonPayload})
 }
 
+// WithPage sends a response containing a page of an offset paginated list.
+func WithPage(w http.ResponseWriter, code int, jsonPayload interface{}, meta pagination.Meta, links pagination.Links) {
+	respond(w, code, Paginated{Base: Base{Data: &jsonPayload}, Meta: meta, Links: links})
+}
+
+// WithCursor sends a response containing a page of a keyset paginated list
+// and the cursors of the pages around it. Empty cursors are left out.
+func WithCursor(w http.ResponseWriter, code int, jsonPayload interface{}, meta pagination.Meta, links pagination.Links, nextCursor, prevCursor string) {
+	payload := Paginated{Base: Base{Data: &jsonPayload}, Meta: meta, Links: links}
+	if nextCursor != "" {
+		payload.NextCursor = &nextCursor
+	}
+	if prevCursor != "" {
+		payload.PrevCursor = &prevCursor
+	}
+	respond(w, code, payload)
+}
+
 // WithError sends a response with an error message
 func WithError(w http.ResponseWriter, err error) {
 	code := failure.GetCode(err)
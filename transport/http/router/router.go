@@ -7,8 +7,15 @@ import (
 
 // DomainHandlers is a struct that contains all domain-specific handlers.
 type DomainHandlers struct {
+	BlobHandler      handlers.BlobHandler
+	BrandHandler     handlers.BrandHandler
+	InventoryHandler handlers.InventoryHandler
 	MaterialsHandler handlers.MaterialsHandler
+	OauthHandler     handlers.OauthHandler
 	ProductHandler   handlers.ProductHandler
+	UserHandler      handlers.UserHandler
+	VariantHandler   handlers.VariantHandler
+	WarehouseHandler handlers.WarehouseHandler
 }
 
 // Router is the router struct containing handlers.
@@ -25,8 +32,16 @@ func ProvideRouter(domainHandlers DomainHandlers) Router {
 
 // SetupRoutes sets up all routing for this server.
 func (r *Router) SetupRoutes(mux *chi.Mux) {
+	r.DomainHandlers.BlobHandler.Router(mux)
+	r.DomainHandlers.OauthHandler.Router(mux)
+
 	mux.Route("/v1", func(rc chi.Router) {
+		r.DomainHandlers.BrandHandler.Router(rc)
+		r.DomainHandlers.InventoryHandler.Router(rc)
 		r.DomainHandlers.MaterialsHandler.Router(rc)
 		r.DomainHandlers.ProductHandler.Router(rc)
+		r.DomainHandlers.UserHandler.Router(rc)
+		r.DomainHandlers.VariantHandler.Router(rc)
+		r.DomainHandlers.WarehouseHandler.Router(rc)
 	})
 }
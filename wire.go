@@ -9,12 +9,18 @@ import (
 	// fooBarBazEvent "github.com/evermos/boilerplate-go/event/domain/foobarbaz"
 	// "github.com/evermos/boilerplate-go/event/producer"
 	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/internal/domain/brand"
+	"github.com/evermos/boilerplate-go/internal/domain/image"
 	// "github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
 	"github.com/evermos/boilerplate-go/internal/domain/materials"
 	"github.com/evermos/boilerplate-go/internal/domain/products"
+	"github.com/evermos/boilerplate-go/internal/domain/user"
+	"github.com/evermos/boilerplate-go/internal/domain/variants"
+	"github.com/evermos/boilerplate-go/internal/domain/warehouse"
 	"github.com/evermos/boilerplate-go/internal/handlers"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http"
-	// "github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/router"
 	"github.com/google/wire"
 )
@@ -27,6 +33,7 @@ var configurations = wire.NewSet(
 // Wiring for persistences.
 var persistences = wire.NewSet(
 	infras.ProvideMariaDBConn,
+	infras.ProvideBlobStore,
 )
 
 // Wiring for domain FooBarBaz.
@@ -56,21 +63,79 @@ var domainProducts = wire.NewSet(
 	wire.Bind(new(products.ProductRepository), new(*products.ProductRepositoryMariaDB)),
 )
 
+var domainVariants = wire.NewSet(
+	variants.ProvideVariantServiceImpl,
+	wire.Bind(new(variants.VariantService), new(*variants.VariantServiceImpl)),
+	variants.ProvideVariantRepositoryMariaDB,
+	wire.Bind(new(variants.VariantRepository), new(*variants.VariantRepositoryMariaDB)),
+	variants.ProvideInventoryServiceImpl,
+	wire.Bind(new(variants.InventoryService), new(*variants.InventoryServiceImpl)),
+	variants.ProvideInventoryRepositoryMariaDB,
+	wire.Bind(new(variants.InventoryRepository), new(*variants.InventoryRepositoryMariaDB)),
+)
+
+var domainImage = wire.NewSet(
+	image.ProvideImageServiceImpl,
+	wire.Bind(new(image.ImageService), new(*image.ImageServiceImpl)),
+	image.ProvideImageRepositoryMariaDB,
+	wire.Bind(new(image.ImageRepository), new(*image.ImageRepositoryMariaDB)),
+)
+
+var domainBrand = wire.NewSet(
+	brand.ProvideBrandServiceImpl,
+	wire.Bind(new(brand.BrandService), new(*brand.BrandServiceImpl)),
+	brand.ProvideBrandRepositoryMariaDB,
+	wire.Bind(new(brand.BrandRepository), new(*brand.BrandRepositoryMariaDB)),
+)
+
+var domainWarehouse = wire.NewSet(
+	warehouse.ProvideWarehouseServiceImpl,
+	wire.Bind(new(warehouse.WarehouseService), new(*warehouse.WarehouseServiceImpl)),
+	warehouse.ProvideWarehouseRepositoryMariaDB,
+	wire.Bind(new(warehouse.WarehouseRepository), new(*warehouse.WarehouseRepositoryMariaDB)),
+)
+
+var domainUser = wire.NewSet(
+	user.ProvideUserServiceImpl,
+	wire.Bind(new(user.UserService), new(*user.UserServiceImpl)),
+	user.ProvideUserRepositoryMariaDB,
+	wire.Bind(new(user.UserRepository), new(*user.UserRepositoryMariaDB)),
+)
+
 // Wiring for all domains.
 var domains = wire.NewSet(
-	domainMaterials, domainProducts,
+	domainMaterials, domainProducts, domainVariants, domainImage, domainBrand, domainWarehouse, domainUser,
 )
 
-// var authMiddleware = wire.NewSet(
-// 	middleware.ProvideAuthentication,
-// )
+// Wiring for the in-process worker pool.
+var pubsubs = wire.NewSet(
+	infras.ProvidePubSub,
+)
+
+// Wiring for pagination.
+var paginations = wire.NewSet(
+	infras.ProvideCursorCodec,
+)
+
+var authMiddleware = wire.NewSet(
+	infras.ProvideOauthConfig,
+	middleware.ProvideAuthentication,
+	middleware.ProvideAuthorization,
+)
 
 // Wiring for HTTP routing.
 var routing = wire.NewSet(
-	wire.Struct(new(router.DomainHandlers), "MaterialsHandler", "ProductHandler"),
+	wire.Struct(new(router.DomainHandlers), "BrandHandler", "InventoryHandler", "MaterialsHandler", "OauthHandler", "ProductHandler", "UserHandler", "VariantHandler", "WarehouseHandler"),
 	router.ProvideRouter,
+	handlers.ProvideBlobHandler,
+	handlers.ProvideBrandHandler,
+	handlers.ProvideInventoryHandler,
 	handlers.ProvideMaterialsHandler,
+	handlers.ProvideOauthHandler,
 	handlers.ProvideProductHandler,
+	handlers.ProvideUserHandler,
+	handlers.ProvideVariantHandler,
+	handlers.ProvideWarehouseHandler,
 )
 
 // Wiring for all domains event consumer.
@@ -86,8 +151,12 @@ func InitializeService() *http.HTTP {
 		configurations,
 		// persistences
 		persistences,
+		// worker pool
+		pubsubs,
 		// middleware
-		// authMiddleware,
+		authMiddleware,
+		// pagination
+		paginations,
 		// domains
 		domains,
 		// routing
@@ -97,6 +166,77 @@ func InitializeService() *http.HTTP {
 	return &http.HTTP{}
 }
 
+// Wiring the background sweeper of expired stock reservations.
+func InitializeReservationSweeper() *variants.ReservationSweeper {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// domains
+		domains,
+		// sweeper
+		variants.ProvideReservationSweeper)
+	return &variants.ReservationSweeper{}
+}
+
+// Wiring the background renderer of image renditions.
+func InitializeRenditionWorker() *image.RenditionWorker {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// worker pool
+		pubsubs,
+		// domains
+		domains,
+		// worker
+		image.ProvideRenditionWorker)
+	return &image.RenditionWorker{}
+}
+
+// Wiring the background runner of product imports.
+func InitializeProductImportWorker() *products.ImportWorker {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// worker pool
+		pubsubs,
+		// domains
+		domains,
+		// worker
+		products.ProvideImportWorker)
+	return &products.ImportWorker{}
+}
+
+// Wiring the startup hashing of plaintext client secrets.
+func InitializeOauthToken() *oauth.Token {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// oauth
+		infras.ProvideOauthConfig,
+		infras.ProvideOauthToken)
+	return &oauth.Token{}
+}
+
+// Wiring the startup derivation of variant statuses.
+func InitializeVariantService() variants.VariantService {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// domains
+		domainVariants)
+	return &variants.VariantServiceImpl{}
+}
+
 // Wiring the event needs.
 // func InitializeEvent() event.Consumers {
 // 	wire.Build(